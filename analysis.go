@@ -0,0 +1,121 @@
+package ns_x
+
+import (
+	"errors"
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/node"
+	"sort"
+	"time"
+)
+
+// PathAnalysis is the analysis of a path by models and limits of nodes on it, without running the network
+// delays are the ones configured, not including time queued by restrict nodes
+type PathAnalysis struct {
+	// Nodes of the path, including both ends, and internal nodes of node.SubnetNode passed through
+	Nodes []base.Node
+	// MinDelay is the theoretical minimum one-way delay, sum of lower bounds of delay and reorder models
+	MinDelay time.Duration
+	// MeanDelay is the expected one-way delay, sum of expected delays of delay and reorder models,
+	// node.MaxDuration if unbounded, such as pareto delays with alpha not greater than 1
+	MeanDelay time.Duration
+	// Loss is the expected loss rate along the path, assuming losses independent
+	Loss float64
+	// PPS is the bottleneck limit in packets per second, -1 if unlimited
+	PPS float64
+	// PPSBottleneck is the RestrictNode with the least pps limit, nil if unlimited
+	PPSBottleneck *node.RestrictNode
+	// BPS is the bottleneck limit in bytes per second, -1 if unlimited
+	BPS float64
+	// BPSBottleneck is the RestrictNode with the least bps limit, nil if unlimited
+	BPSBottleneck *node.RestrictNode
+	// Unknown are channels with models without description, such as user-defined functions, which are not counted in
+	// delays or loss, so that results are only bounds of the path
+	Unknown []*node.ChannelNode
+}
+
+// Analyze paths from the node to the target of the given names, by models described like math.NewFixedDelayModel and limits
+// of restrict nodes like node.WithBPSLimit, so that scenarios can be checked before running and compared with measured results
+// at most limit paths with the least minimum delay are analysed by Yen's algorithm, all paths if limit not positive,
+// paths with less minimum delay first
+// return error if no node with the given names, or no path between them
+func (n *Network) Analyze(from, to string, limit int) ([]*PathAnalysis, error) {
+	source, target := n.NodeOfName(from), n.NodeOfName(to)
+	if source == nil {
+		return nil, errors.New("no node with name " + from)
+	}
+	if target == nil {
+		return nil, errors.New("no node with name " + to)
+	}
+	// packets transferred to a subnet node go through its internal nodes from the ingress node
+	next := func(n base.Node) []base.Node {
+		if subnet, ok := n.(*node.SubnetNode); ok {
+			return []base.Node{subnet.Ingress()}
+		}
+		return n.GetNext()
+	}
+	var found [][]base.Node
+	if limit > 0 {
+		found = cheapestPaths(source, target, limit, func(n base.Node) int64 {
+			return int64(minDelay(n))
+		}, next)
+	} else {
+		found = paths(source, target, 0, next)
+	}
+	if len(found) == 0 {
+		return nil, errors.New("no path from " + from + " to " + to)
+	}
+	result := make([]*PathAnalysis, len(found))
+	for i, path := range found {
+		result[i] = analyze(path)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].MinDelay < result[j].MinDelay
+	})
+	return result, nil
+}
+
+// analyze the path
+func analyze(path []base.Node) *PathAnalysis {
+	a := &PathAnalysis{Nodes: path, PPS: -1, BPS: -1}
+	pass := 1.0
+	for _, n := range path {
+		switch n := n.(type) {
+		case *node.ChannelNode:
+			min, minKnown := n.MinDelay()
+			mean, meanKnown := n.MeanDelay()
+			rate, rateKnown := n.LossRate()
+			if !minKnown || !meanKnown || !rateKnown {
+				a.Unknown = append(a.Unknown, n)
+			}
+			a.MinDelay += min
+			a.MeanDelay = addMean(a.MeanDelay, mean)
+			pass *= 1 - rate
+		case *node.RestrictNode:
+			if pps := n.PPSLimit(); pps >= 0 && (a.PPS < 0 || pps < a.PPS) {
+				a.PPS, a.PPSBottleneck = pps, n
+			}
+			if bps := n.BPSLimit(); bps >= 0 && (a.BPS < 0 || bps < a.BPS) {
+				a.BPS, a.BPSBottleneck = bps, n
+			}
+		}
+	}
+	a.Loss = 1 - pass
+	return a
+}
+
+// addMean add expected delays, saturated to node.MaxDuration as unbounded once overflow
+func addMean(a, b time.Duration) time.Duration {
+	if a == node.MaxDuration || b == node.MaxDuration || (b > 0 && a > node.MaxDuration-b) {
+		return node.MaxDuration
+	}
+	return a + b
+}
+
+// minDelay of the node, channels with models without description are regarded as no delay, same to analyze
+func minDelay(n base.Node) time.Duration {
+	if channel, ok := n.(*node.ChannelNode); ok {
+		delay, _ := channel.MinDelay()
+		return delay
+	}
+	return 0
+}
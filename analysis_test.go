@@ -0,0 +1,94 @@
+package ns_x
+
+import (
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/math"
+	"github.com/bytedance/ns-x/v2/node"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestAnalyze(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	subnet, err := BuildSubnet(func(builder Builder) {
+		builder.Chain().
+			NodeWithName("limit", node.NewRestrictNode(node.WithBPSLimit(5e5, -1))).
+			NodeWithName("link", node.NewChannelNode(node.WithDescribedDelay(math.NewFixedDelayModel(20*time.Millisecond)), node.WithDescribedLoss(math.NewRandomLossModel(0.5, random))))
+	}, "limit", "link")
+	assert.NoError(t, err)
+	custom := func(packet base.Packet) bool {
+		return false
+	}
+	network, nodes, err := NewBuilder().
+		Chain().
+		NodeWithName("sender", node.NewEndpointNode()).
+		NodeWithName("limit", node.NewRestrictNode(node.WithPPSLimit(1000, -1), node.WithBPSLimit(1e6, -1))).
+		NodeWithName("wan", node.NewChannelNode(node.WithDescribedDelay(math.NewFixedDelayModel(10*time.Millisecond)), node.WithDescribedLoss(math.NewRandomLossModel(0.1, random)))).
+		NodeWithName("split", node.NewBroadcastNode()).
+		NodeWithName("subnet", subnet).
+		NodeWithName("join", node.NewGatherNode()).
+		NodeWithName("receiver", node.NewEndpointNode()).
+		Chain().
+		NodeOfName("split").
+		NodeWithName("lan", node.NewChannelNode(node.WithDescribedDelay(math.NewFixedDelayModel(5*time.Millisecond)), node.WithDescribedDelay(math.NewUniformDelayModel(10*time.Millisecond, random)), node.WithLoss(custom))).
+		NodeOfName("join").
+		Build()
+	assert.NoError(t, err)
+
+	result, err := network.Analyze("sender", "receiver", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(result))
+	direct, through := result[0], result[1]
+	assert.Equal(t, []base.Node{nodes["sender"], nodes["limit"], nodes["wan"], nodes["split"], nodes["lan"], nodes["join"], nodes["receiver"]}, direct.Nodes)
+	assert.Equal(t, 15*time.Millisecond, direct.MinDelay)
+	assert.Equal(t, 25*time.Millisecond, direct.MeanDelay)
+	assert.InDelta(t, 0.1, direct.Loss, 1e-9)
+	assert.Equal(t, 1000.0, direct.PPS)
+	assert.Same(t, nodes["limit"], direct.PPSBottleneck)
+	assert.Equal(t, 1e6, direct.BPS)
+	assert.Same(t, nodes["limit"], direct.BPSBottleneck)
+	assert.Equal(t, []*node.ChannelNode{nodes["lan"].(*node.ChannelNode)}, direct.Unknown)
+
+	// internal nodes of the subnet are analysed
+	assert.Equal(t, 9, len(through.Nodes))
+	assert.Equal(t, 30*time.Millisecond, through.MinDelay)
+	assert.Equal(t, 30*time.Millisecond, through.MeanDelay)
+	assert.InDelta(t, 0.55, through.Loss, 1e-9)
+	assert.Same(t, nodes["limit"], through.PPSBottleneck)
+	assert.Equal(t, 5e5, through.BPS)
+	assert.Same(t, subnet.Ingress(), through.BPSBottleneck)
+	assert.Nil(t, through.Unknown)
+
+	// the path with the least minimum delay is kept, though found later in depth first order
+	result, err = network.Analyze("sender", "receiver", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(result))
+	assert.Equal(t, direct.Nodes, result[0].Nodes)
+	result, err = network.Analyze("sender", "receiver", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(result))
+	assert.Equal(t, through.Nodes, result[1].Nodes)
+	_, err = network.Analyze("sender", "none", 0)
+	assert.EqualError(t, err, "no node with name none")
+	_, err = network.Analyze("receiver", "sender", 0)
+	assert.EqualError(t, err, "no path from receiver to sender")
+}
+
+func TestAnalyzeUnboundedMean(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	network, _, err := NewBuilder().
+		Chain().
+		NodeWithName("sender", node.NewEndpointNode()).
+		Node(node.NewChannelNode(node.WithDescribedDelay(math.NewParetoDelayModel(time.Millisecond, 1, random)), node.WithDescribedDelay(math.NewFixedDelayModel(time.Millisecond)))).
+		Node(node.NewChannelNode(node.WithDescribedDelay(math.NewFixedDelayModel(time.Millisecond)))).
+		NodeWithName("receiver", node.NewEndpointNode()).
+		Build()
+	assert.NoError(t, err)
+	result, err := network.Analyze("sender", "receiver", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(result))
+	assert.Equal(t, 3*time.Millisecond, result[0].MinDelay)
+	assert.Equal(t, node.MaxDuration, result[0].MeanDelay)
+}
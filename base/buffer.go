@@ -7,13 +7,15 @@ import (
 
 // EventBuffer is a thread-safe, lock-free buffer used to store simulated packets, implemented like a single link list
 type EventBuffer struct {
-	node *atomic.UnsafePointer
+	node   *atomic.UnsafePointer
+	signal chan struct{} // notified once events inserted, at most one notification pending
 }
 
 // NewEventBuffer creates a new packet buffer
 func NewEventBuffer() *EventBuffer {
 	return &EventBuffer{
-		node: atomic.NewUnsafePointer(nil),
+		node:   atomic.NewUnsafePointer(nil),
+		signal: make(chan struct{}, 1),
 	}
 }
 
@@ -30,14 +32,37 @@ func (b *EventBuffer) Insert(events ...Event) {
 			n.next = (*node)(b.node.Load())
 		}
 	}
+	b.Notify()
 }
 
-// Reduce means clear the buffer and do an action on the event cleared, thread-safe
+// Notify wake up the one waiting for the buffer by Signal, even if no events inserted, thread-safe
+func (b *EventBuffer) Notify() {
+	select {
+	case b.signal <- struct{}{}:
+	default:
+	}
+}
+
+// Signal return a channel notified once events inserted since the last notification received, or Notify called
+func (b *EventBuffer) Signal() <-chan struct{} {
+	return b.signal
+}
+
+// Reduce means clear the buffer and do an action on the event cleared in the order of insertion, thread-safe
 func (b *EventBuffer) Reduce(action func(event Event)) {
+	if b.node.Load() == nil {
+		return
+	}
 	n := b.node.Swap(nil)
-	node := (*node)(n)
-	for node != nil {
+	// nodes are linked from the latest one, reverse them to keep the order of insertion
+	var reversed *node
+	for node := (*node)(n); node != nil; {
+		next := node.next
+		node.next = reversed
+		reversed = node
+		node = next
+	}
+	for node := reversed; node != nil; node = node.next {
 		action(node.event)
-		node = node.next
 	}
 }
@@ -1,6 +1,7 @@
 package base
 
 import (
+	"github.com/stretchr/testify/assert"
 	"testing"
 	"time"
 )
@@ -16,3 +17,21 @@ func BenchmarkBuffer(b *testing.B) {
 	}
 	buffer.Reduce(callback)
 }
+
+func TestBufferOrder(t *testing.T) {
+	buffer := NewEventBuffer()
+	now := time.Now()
+	count := 100
+	for i := 0; i < count; i++ {
+		buffer.Insert(NewFixedEvent(nil, now.Add(time.Duration(i))))
+	}
+	index := 0
+	buffer.Reduce(func(event Event) {
+		assert.Equal(t, now.Add(time.Duration(index)), event.Time())
+		index++
+	})
+	assert.Equal(t, count, index)
+	buffer.Reduce(func(event Event) {
+		t.Fail()
+	})
+}
@@ -0,0 +1,35 @@
+package base
+
+// Cause describes where an event comes from, assigned by the simulation once the event is scheduled
+type Cause struct {
+	// ID of the event, unique in a simulation and starting from 1, 0 if not assigned yet
+	ID uint64
+	// Parent is the ID of the event whose action created this event, 0 if scheduled from outside the simulation
+	Parent uint64
+	// Origin is the node whose action created this event, see Originated, nil if none
+	Origin Node
+}
+
+// Causal is an event carrying its Cause, the cause is filled by the simulation, not by users
+type Causal interface {
+	Event
+	// Cause of the event
+	Cause() *Cause
+}
+
+// Originated is an event created by a node other than the one bound to its parent event,
+// such as events transferring packets, which are created by the node passing the packet rather than its sender
+// the origin of other events is the node bound to their parent event
+type Originated interface {
+	Event
+	// Origin return the node created the event
+	Origin() Node
+}
+
+// CauseOf return the cause of the event, nil if the event is not Causal
+func CauseOf(event Event) *Cause {
+	if c, ok := event.(Causal); ok {
+		return c.Cause()
+	}
+	return nil
+}
@@ -10,6 +10,8 @@ type Event interface {
 	Time() time.Time
 	// Action what to do
 	Action() Action
+	// Node where the action takes effect, nil if not bound to any node
+	Node() Node
 	// HookBefore hook the event with the given action handled before the actual action
 	HookBefore(action Action)
 	// HookAfter hook the event with the given action handled after the actual action
@@ -17,8 +19,61 @@ type Event interface {
 }
 
 type event struct {
-	time   time.Time
-	action Action
+	time     time.Time
+	action   Action
+	node     Node
+	priority Priority
+	cause    Cause
+}
+
+// Priority of events at the same time point, events with lower priority are handled first
+type Priority int
+
+const (
+	// PriorityControl is for control actions, such as links going down or routing tables swapped,
+	// which take effect before packets at the same time point
+	PriorityControl Priority = -1
+	// PriorityDefault is the priority of events created without priority
+	PriorityDefault Priority = 0
+)
+
+// Prioritized is an event with a priority, events at the same time point are sorted by priority, then the order of enqueue
+// events not Prioritized are in PriorityDefault
+type Prioritized interface {
+	Event
+	// Priority of the event
+	Priority() Priority
+}
+
+// Handler is an event handled without building the closure of its action, to avoid allocations in the hot path
+// Action of a Handler must still be valid, and behave the same as Handle
+type Handler interface {
+	Event
+	// Handle the event at the given time, return following events of this event
+	Handle(t time.Time) []Event
+}
+
+// Recyclable is an event which can be reused once handled, such as events allocated from a pool
+// the simulation recycles an event after its following events are scheduled, the event must not be used after that
+type Recyclable interface {
+	Event
+	// Recycle the event
+	Recycle()
+}
+
+// Act the event at the given time, through Handle if the event is a Handler, return following events of this event
+func Act(event Event, t time.Time) []Event {
+	if h, ok := event.(Handler); ok {
+		return h.Handle(t)
+	}
+	return event.Action()(t)
+}
+
+// Recycle the event if it is Recyclable
+func Recycle(event Event) {
+	if r, ok := event.(Recyclable); ok {
+		r.Recycle()
+	}
 }
 
 // Action is what to do of an event, time of the event is passed in, return following events of this event
@@ -36,6 +91,18 @@ func (e *event) Action() Action {
 	return e.action
 }
 
+func (e *event) Node() Node {
+	return e.node
+}
+
+func (e *event) Priority() Priority {
+	return e.priority
+}
+
+func (e *event) Cause() *Cause {
+	return &e.cause
+}
+
 func (e *event) HookBefore(action Action) {
 	actualAction := e.action
 	e.action = func(t time.Time) (events []Event) {
@@ -69,6 +136,26 @@ func NewFixedEvent(action Action, time time.Time) Event {
 	return &event{time: time, action: action}
 }
 
+// NewFixedEventWithPriority same to NewFixedEvent, but handled in the order of the given priority at the same time point
+func NewFixedEventWithPriority(action Action, time time.Time, priority Priority) Event {
+	return &event{time: time, action: action, priority: priority}
+}
+
+// NewDelayedEventWithPriority same to NewDelayedEvent, but handled in the order of the given priority at the same time point
+func NewDelayedEventWithPriority(action Action, delay time.Duration, now time.Time, priority Priority) Event {
+	return NewFixedEventWithPriority(action, now.Add(delay), priority)
+}
+
+// NewNodeEvent create an event at the time point, whose action takes effect on the given node
+func NewNodeEvent(node Node, action Action, time time.Time) Event {
+	return &event{time: time, action: action, node: node}
+}
+
+// NewDelayedNodeEvent create an event with delay, whose action takes effect on the given node
+func NewDelayedNodeEvent(node Node, action Action, delay time.Duration, now time.Time) Event {
+	return NewNodeEvent(node, action, now.Add(delay))
+}
+
 // NewPeriodicEvent create a periodic event, generate itself each time
 func NewPeriodicEvent(action Action, period time.Duration, t time.Time) Event {
 	return NewRepeatEvent(func(now time.Time) ([]Event, time.Duration) {
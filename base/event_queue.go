@@ -1,19 +1,25 @@
 package base
 
 import (
-	"container/heap"
 	"time"
 )
 
-// EventQueue is used to sort events according to the time of events
+// EventQueue is an EventScheduler implemented by a calendar queue, which sorts events into buckets of time first,
+// and then use a heap sort for each bucket
+// events at the same time point are sorted by priority and then the order of enqueue, so that the order is deterministic
+// Cancellable events are removed lazily, once cancelled events reach the head of the queue
 type EventQueue struct {
 	total         int
+	sequence      uint64
 	buckets       *Queue
 	threshold     time.Time
 	bucketSize    time.Duration
 	maxBuckets    int
 	currentBucket *bucket
 	defaultBucket *bucket
+	adaptive      bool
+	gaps          []time.Duration // gaps sampled since last adaption
+	last          time.Time       // time of the last event dequeued
 }
 
 func NewEventQueue(bucketSize time.Duration, maxBuckets int) *EventQueue {
@@ -27,8 +33,32 @@ func NewEventQueue(bucketSize time.Duration, maxBuckets int) *EventQueue {
 	}
 }
 
+const (
+	// adaptSamples is the min count of gaps sampled before each adaption
+	adaptSamples = 1024
+	// adaptEventsPerBucket is the expected count of events in each bucket after adaption
+	adaptEventsPerBucket = 3
+	// adaptMinBuckets and adaptMaxBuckets limit the max bucket count after adaption
+	adaptMinBuckets = 64
+	adaptMaxBuckets = 1 << 16
+)
+
+// NewAdaptiveEventQueue create an EventQueue tuning the bucket size and max bucket count by itself
+// gaps between events dequeued are sampled, and buckets are rebuilt once the sampled gaps differs a lot from the bucket size,
+// so that each bucket holds a few events, and buckets cover the events pending as much as possible
+func NewAdaptiveEventQueue() *EventQueue {
+	q := NewEventQueue(time.Millisecond, adaptMinBuckets)
+	q.adaptive = true
+	return q
+}
+
 func (q *EventQueue) Enqueue(event Event) {
-	t := event.Time()
+	q.enqueue(newItem(event, q.sequence))
+	q.sequence++
+}
+
+func (q *EventQueue) enqueue(item item) {
+	t := item.event.Time()
 	if q.total <= 0 {
 		q.threshold = t.Add(q.bucketSize)
 	}
@@ -44,16 +74,25 @@ func (q *EventQueue) Enqueue(event Event) {
 			b = q.buckets.At(index).(*bucket)
 		}
 	}
-	heap.Push(b, event)
+	b.push(item)
 	q.total++
 }
 
-func (q *EventQueue) Dequeue() Event {
+func (q *EventQueue) Dequeue() (Event, bool) {
+	q.purge()
 	if q.currentBucket.IsEmpty() {
-		panic("no more events")
+		return nil, false
 	}
-	event := heap.Pop(q.currentBucket).(Event)
+	return q.pop(), true
+}
+
+// pop the first event, no matter whether cancelled
+func (q *EventQueue) pop() Event {
+	event := q.currentBucket.pop().event
 	q.total--
+	if q.adaptive {
+		defer q.sample(event.Time())
+	}
 	for q.currentBucket.IsEmpty() {
 		if q.buckets.IsEmpty() {
 			break
@@ -61,36 +100,137 @@ func (q *EventQueue) Dequeue() Event {
 		q.currentBucket = q.buckets.Dequeue().(*bucket)
 		q.threshold = q.threshold.Add(q.bucketSize)
 	}
+	if q.currentBucket.IsEmpty() && !q.defaultBucket.IsEmpty() {
+		// no events in buckets, skip to the earliest event in default bucket
+		q.threshold = q.defaultBucket.Peek().event.Time().Add(q.bucketSize)
+	}
 	t := q.threshold.Add(q.bucketSize * time.Duration(q.maxBuckets))
 	for !q.defaultBucket.IsEmpty() {
 		e := q.defaultBucket.Peek()
-		if e.Time().After(t) {
+		if e.event.Time().After(t) {
 			break
 		}
-		heap.Pop(q.defaultBucket)
+		q.defaultBucket.pop()
 		q.total--
-		q.Enqueue(e)
+		q.enqueue(e)
 	}
 	return event
 }
 
-func (q *EventQueue) Peek() Event {
+// sample the gap between events dequeued, and adapt buckets once enough samples collected
+func (q *EventQueue) sample(t time.Time) {
+	if !q.last.IsZero() {
+		q.gaps = append(q.gaps, t.Sub(q.last))
+	}
+	q.last = t
+	// sample at least as many as events in the queue, so that the cost of rebuild is amortized
+	if len(q.gaps) < adaptSamples || len(q.gaps) < q.total {
+		return
+	}
+	bucketSize := separation(q.gaps) * adaptEventsPerBucket
+	q.gaps = q.gaps[:0]
+	if bucketSize <= 0 {
+		return
+	}
+	maxBuckets := adaptMinBuckets
+	for maxBuckets < q.total && maxBuckets < adaptMaxBuckets {
+		maxBuckets <<= 1
+	}
+	if bucketSize*2 < q.bucketSize || bucketSize > q.bucketSize*2 || maxBuckets > q.maxBuckets*2 || maxBuckets*4 < q.maxBuckets {
+		q.rebuild(bucketSize, maxBuckets)
+	}
+}
+
+// separation estimate the average separation of events from the given gaps
+// as the calendar queue does, gaps larger than twice the average are excluded, so that a few long gaps have no effects
+func separation(gaps []time.Duration) time.Duration {
+	total := time.Duration(0)
+	for _, gap := range gaps {
+		total += gap
+	}
+	limit := 2 * total / time.Duration(len(gaps))
+	total, count := 0, 0
+	for _, gap := range gaps {
+		if gap <= limit {
+			total += gap
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / time.Duration(count)
+}
+
+// rebuild buckets with the given parameters, the order of events is kept
+func (q *EventQueue) rebuild(bucketSize time.Duration, maxBuckets int) {
+	items := append([]item(nil), q.currentBucket.storage...)
+	for !q.buckets.IsEmpty() {
+		items = append(items, q.buckets.Dequeue().(*bucket).storage...)
+	}
+	items = append(items, q.defaultBucket.storage...)
+	q.bucketSize, q.maxBuckets = bucketSize, maxBuckets
+	q.currentBucket, q.defaultBucket = &bucket{}, &bucket{}
+	q.buckets = NewQueue(0)
+	q.total = 0
+	if len(items) == 0 {
+		return
+	}
+	// the earliest event decides the threshold, so enqueue it first
+	first := 0
+	for i := range items {
+		if items[i].event.Time().Before(items[first].event.Time()) {
+			first = i
+		}
+	}
+	items[0], items[first] = items[first], items[0]
+	for _, item := range items {
+		q.enqueue(item)
+	}
+}
+
+// purge cancelled events at the head of the queue
+func (q *EventQueue) purge() {
+	for !q.currentBucket.IsEmpty() && q.currentBucket.Peek().cancelled() {
+		q.pop()
+	}
+}
+
+func (q *EventQueue) Peek() (Event, bool) {
+	q.purge()
 	if q.currentBucket.IsEmpty() {
-		panic("no more events")
+		return nil, false
 	}
-	return q.currentBucket.Peek()
+	return q.currentBucket.Peek().event, true
 }
 
+// Length of the queue, including cancelled events not removed yet
 func (q *EventQueue) Length() int {
 	return q.total
 }
 
 func (q *EventQueue) IsEmpty() bool {
+	q.purge()
 	return q.Length() <= 0
 }
 
+// item is an event with the sequence number assigned when enqueued
+type item struct {
+	event    Event
+	priority Priority
+	sequence uint64
+}
+
+func newItem(event Event, sequence uint64) item {
+	i := item{event: event, sequence: sequence}
+	if p, ok := event.(Prioritized); ok {
+		i.priority = p.Priority()
+	}
+	return i
+}
+
 type bucket struct {
-	storage []Event
+	storage []item
 }
 
 func (b *bucket) IsEmpty() bool {
@@ -98,9 +238,15 @@ func (b *bucket) IsEmpty() bool {
 }
 
 func (b *bucket) Less(i, j int) bool {
-	ti := b.storage[i].Time()
-	tj := b.storage[j].Time()
-	return ti.Before(tj)
+	ti := b.storage[i].event.Time()
+	tj := b.storage[j].event.Time()
+	if !ti.Equal(tj) {
+		return ti.Before(tj)
+	}
+	if b.storage[i].priority != b.storage[j].priority {
+		return b.storage[i].priority < b.storage[j].priority
+	}
+	return b.storage[i].sequence < b.storage[j].sequence
 }
 
 func (b *bucket) Len() int {
@@ -111,16 +257,51 @@ func (b *bucket) Swap(i, j int) {
 	b.storage[i], b.storage[j] = b.storage[j], b.storage[i]
 }
 
-func (b *bucket) Push(x interface{}) {
-	b.storage = append(b.storage, x.(Event))
+// push the item into the heap, typed to avoid allocations of container/heap
+func (b *bucket) push(x item) {
+	b.storage = append(b.storage, x)
+	b.up(b.Len() - 1)
 }
 
-func (b *bucket) Pop() interface{} {
-	x := b.storage[b.Len()-1]
-	b.storage = b.storage[:b.Len()-1]
+// pop the first item of the heap
+func (b *bucket) pop() item {
+	n := b.Len() - 1
+	b.Swap(0, n)
+	b.down(0, n)
+	x := b.storage[n]
+	b.storage[n] = item{}
+	b.storage = b.storage[:n]
 	return x
 }
 
-func (b *bucket) Peek() Event {
+func (b *bucket) up(j int) {
+	for {
+		i := (j - 1) / 2 // parent
+		if i == j || !b.Less(j, i) {
+			break
+		}
+		b.Swap(i, j)
+		j = i
+	}
+}
+
+func (b *bucket) down(i, n int) {
+	for {
+		j := 2*i + 1
+		if j >= n || j < 0 {
+			break
+		}
+		if r := j + 1; r < n && b.Less(r, j) {
+			j = r
+		}
+		if !b.Less(j, i) {
+			break
+		}
+		b.Swap(i, j)
+		i = j
+	}
+}
+
+func (b *bucket) Peek() item {
 	return b.storage[0]
 }
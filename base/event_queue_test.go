@@ -23,7 +23,7 @@ func TestEventQueue(t *testing.T) {
 	assert.Equal(t, count, eventQueue.Length())
 	last := int64(math.MinInt64)
 	for !eventQueue.IsEmpty() {
-		p := eventQueue.Dequeue()
+		p := dequeue(eventQueue)
 		unix := p.Time().Unix()
 		assert.GreaterOrEqual(t, unix, last)
 		last = unix
@@ -39,7 +39,7 @@ func BenchmarkEventQueue(b *testing.B) {
 		}, now.Add(time.Duration(rand.Int()%256)*time.Second)))
 	}
 	for !eventQueue.IsEmpty() {
-		_ = eventQueue.Dequeue()
+		_ = dequeue(eventQueue)
 	}
 }
 
@@ -63,7 +63,7 @@ func BenchmarkDequeue(b *testing.B) {
 	}
 	b.ResetTimer()
 	for !eventQueue.IsEmpty() {
-		_ = eventQueue.Dequeue()
+		_ = dequeue(eventQueue)
 	}
 }
 
@@ -74,6 +74,128 @@ func BenchmarkEnqueueAndDequeue(b *testing.B) {
 		eventQueue.Enqueue(NewFixedEvent(func(t time.Time) []Event {
 			return nil
 		}, now.Add(time.Duration(rand.Int()%256)*time.Second)))
-		_ = eventQueue.Dequeue()
+		_ = dequeue(eventQueue)
 	}
 }
+
+func TestEventQueueSameTime(t *testing.T) {
+	now := time.Now()
+	eventQueue := NewEventQueue(TestBucketSize, TestBucketsLimit)
+	count := 1000
+	events := make([]Event, count)
+	for i := 0; i < count; i++ {
+		events[i] = NewFixedEvent(nil, now.Add(time.Duration(i%4)*time.Minute))
+		eventQueue.Enqueue(events[i])
+	}
+	for offset := 0; offset < 4; offset++ {
+		for i := offset; i < count; i += 4 {
+			assert.Same(t, events[i], dequeue(eventQueue))
+		}
+	}
+}
+
+func TestEventQueueFarEvent(t *testing.T) {
+	now := time.Now()
+	eventQueue := NewEventQueue(TestBucketSize, TestBucketsLimit)
+	far := now.Add(TestBucketSize * TestBucketsLimit * 10)
+	eventQueue.Enqueue(NewFixedEvent(nil, now))
+	eventQueue.Enqueue(NewFixedEvent(nil, far))
+	assert.Equal(t, now, dequeue(eventQueue).Time())
+	assert.False(t, eventQueue.IsEmpty())
+	assert.Equal(t, far, dequeue(eventQueue).Time())
+	assert.True(t, eventQueue.IsEmpty())
+}
+
+// mixedDelay return delays of microseconds mostly, and 600ms sometimes, like datacenter hops next to satellite links
+func mixedDelay(random *rand.Rand) time.Duration {
+	if random.Intn(10) == 0 {
+		return 600*time.Millisecond + time.Duration(random.Intn(1000))*time.Microsecond
+	}
+	return time.Duration(random.Intn(10)+1) * time.Microsecond
+}
+
+// hold dequeue an event and enqueue another one delayed, the classic workload of event queues
+func hold(eventQueue *EventQueue, random *rand.Rand, count int) []time.Time {
+	result := make([]time.Time, 0, count)
+	for i := 0; i < count; i++ {
+		e := dequeue(eventQueue)
+		result = append(result, e.Time())
+		eventQueue.Enqueue(NewFixedEvent(nil, e.Time().Add(mixedDelay(random))))
+	}
+	return result
+}
+
+func TestAdaptiveEventQueue(t *testing.T) {
+	now := time.Now()
+	random := rand.New(rand.NewSource(0))
+	eventQueue := NewAdaptiveEventQueue()
+	for i := 0; i < 1000; i++ {
+		eventQueue.Enqueue(NewFixedEvent(nil, now.Add(mixedDelay(random))))
+	}
+	times := hold(eventQueue, random, 100000)
+	for i := 1; i < len(times); i++ {
+		assert.False(t, times[i].Before(times[i-1]))
+	}
+	assert.NotEqual(t, time.Millisecond, eventQueue.bucketSize)
+	assert.Equal(t, 1000, eventQueue.Length())
+	events := make([]Event, 100)
+	for i := range events {
+		events[i] = NewFixedEvent(nil, times[len(times)-1].Add(time.Duration(i%4)*time.Second))
+		eventQueue.Enqueue(events[i])
+	}
+	eventQueue.rebuild(eventQueue.bucketSize, eventQueue.maxBuckets)
+	previous := time.Time{}
+	var same []Event
+	for !eventQueue.IsEmpty() {
+		e := dequeue(eventQueue)
+		assert.False(t, e.Time().Before(previous))
+		previous = e.Time()
+		for _, event := range events {
+			if event == e {
+				same = append(same, e)
+			}
+		}
+	}
+	for offset := 0; offset < 4; offset++ {
+		for i := offset; i < len(events); i += 4 {
+			assert.Same(t, events[i], same[0])
+			same = same[1:]
+		}
+	}
+}
+
+func TestAdaptiveBucketSize(t *testing.T) {
+	now := time.Now()
+	eventQueue := NewAdaptiveEventQueue()
+	for i := 0; i < 1000; i++ {
+		eventQueue.Enqueue(NewFixedEvent(nil, now.Add(time.Duration(i)*time.Second)))
+	}
+	for i := 0; i < 10000; i++ {
+		e := dequeue(eventQueue)
+		eventQueue.Enqueue(NewFixedEvent(nil, e.Time().Add(1000*time.Second)))
+	}
+	assert.Equal(t, adaptEventsPerBucket*time.Second, eventQueue.bucketSize)
+	assert.Equal(t, 1024, eventQueue.maxBuckets)
+}
+
+func benchmarkHold(b *testing.B, eventQueue *EventQueue) {
+	now := time.Now()
+	random := rand.New(rand.NewSource(0))
+	for i := 0; i < 10000; i++ {
+		eventQueue.Enqueue(NewFixedEvent(nil, now.Add(mixedDelay(random))))
+	}
+	b.ResetTimer()
+	hold(eventQueue, random, b.N)
+}
+
+func BenchmarkHoldFixed(b *testing.B) {
+	benchmarkHold(b, NewEventQueue(TestBucketSize, TestBucketsLimit))
+}
+
+func BenchmarkHoldMistuned(b *testing.B) {
+	benchmarkHold(b, NewEventQueue(time.Nanosecond, 1<<16))
+}
+
+func BenchmarkHoldAdaptive(b *testing.B) {
+	benchmarkHold(b, NewAdaptiveEventQueue())
+}
@@ -0,0 +1,127 @@
+package base
+
+import (
+	"sync"
+	"time"
+)
+
+// Cancellable is an event which can be cancelled after enqueued, cancelled events will never be handled
+type Cancellable interface {
+	Event
+	// Cancelled whether the event is cancelled
+	Cancelled() bool
+}
+
+// Handle of a scheduled event, used to cancel or reschedule the event, thread-safe
+type Handle struct {
+	lock       *sync.Mutex
+	generation uint64 // increased once cancelled or rescheduled, events of previous generations are cancelled
+	current    *handleEvent
+	fired      bool // whether the current event is handled and not repeated
+	priority   Priority
+	action     RepeatAction
+	schedule   func(events ...Event)
+}
+
+// handleEvent is an event managed by a handle
+type handleEvent struct {
+	*event
+	handle     *Handle
+	generation uint64
+}
+
+func (e *handleEvent) Cancelled() bool {
+	e.handle.lock.Lock()
+	defer e.handle.lock.Unlock()
+	return e.generation != e.handle.generation
+}
+
+// NewHandle create a handle of an event with the given action at the given time point
+// schedule is used to insert the event into simulation once rescheduled, such as Network.Inject
+// users need to insert the event of the handle into simulation by themselves for the first time
+func NewHandle(action Action, t time.Time, schedule func(events ...Event)) *Handle {
+	return NewRepeatHandle(func(now time.Time) ([]Event, time.Duration) {
+		return action(now), -1
+	}, t, schedule)
+}
+
+// NewHandleWithPriority same to NewHandle, but events are handled in the order of the given priority at the same time point
+func NewHandleWithPriority(action Action, t time.Time, priority Priority, schedule func(events ...Event)) *Handle {
+	return NewRepeatHandleWithPriority(func(now time.Time) ([]Event, time.Duration) {
+		return action(now), -1
+	}, t, priority, schedule)
+}
+
+// NewRepeatHandle same to NewHandle, but the event is repeated like NewRepeatEvent until cancelled
+func NewRepeatHandle(action RepeatAction, t time.Time, schedule func(events ...Event)) *Handle {
+	return NewRepeatHandleWithPriority(action, t, PriorityDefault, schedule)
+}
+
+// NewRepeatHandleWithPriority same to NewRepeatHandle, but events are handled in the order of the given priority at the same time point
+func NewRepeatHandleWithPriority(action RepeatAction, t time.Time, priority Priority, schedule func(events ...Event)) *Handle {
+	h := &Handle{lock: &sync.Mutex{}, priority: priority, action: action, schedule: schedule}
+	h.current = h.create(t, h.generation)
+	return h
+}
+
+// create an event of the given generation
+func (h *Handle) create(t time.Time, generation uint64) *handleEvent {
+	e := &handleEvent{handle: h, generation: generation}
+	e.event = &event{time: t, priority: h.priority, action: func(now time.Time) []Event {
+		return h.fire(e, now)
+	}}
+	return e
+}
+
+func (h *Handle) fire(e *handleEvent, now time.Time) []Event {
+	events, delay := h.action(now)
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	// cancelled or rescheduled by the action
+	if h.generation != e.generation {
+		return events
+	}
+	if delay < 0 {
+		h.fired = true
+		return events
+	}
+	h.current = h.create(now.Add(delay), e.generation)
+	return append(events, h.current)
+}
+
+// Event return the current event of the handle
+func (h *Handle) Event() Event {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.current
+}
+
+// Time return the time point of the current event
+func (h *Handle) Time() time.Time {
+	return h.Event().Time()
+}
+
+// Cancel the event, do nothing if already cancelled or handled
+func (h *Handle) Cancel() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if !h.fired && h.current.generation == h.generation {
+		h.generation++
+	}
+}
+
+// Cancelled whether the event is cancelled
+func (h *Handle) Cancelled() bool {
+	return h.Event().(*handleEvent).Cancelled()
+}
+
+// Reschedule cancel the current event, and schedule a new one with the same action at the given time point
+func (h *Handle) Reschedule(t time.Time) {
+	h.lock.Lock()
+	h.generation++
+	h.fired = false
+	h.current = h.create(t, h.generation)
+	current := h.current
+	h.lock.Unlock()
+	h.schedule(current)
+}
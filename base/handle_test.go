@@ -0,0 +1,80 @@
+package base
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func drain(eventQueue *EventQueue) []time.Time {
+	var result []time.Time
+	for !eventQueue.IsEmpty() {
+		event := dequeue(eventQueue)
+		result = append(result, event.Time())
+		for _, e := range event.Action()(event.Time()) {
+			eventQueue.Enqueue(e)
+		}
+	}
+	return result
+}
+
+func enqueue(eventQueue *EventQueue) func(events ...Event) {
+	return func(events ...Event) {
+		for _, event := range events {
+			eventQueue.Enqueue(event)
+		}
+	}
+}
+
+func TestHandle(t *testing.T) {
+	now := time.Now()
+	eventQueue := NewEventQueue(TestBucketSize, TestBucketsLimit)
+	noop := func(t time.Time) []Event {
+		return nil
+	}
+	cancelled := NewHandle(noop, now.Add(time.Second), enqueue(eventQueue))
+	rescheduled := NewHandle(noop, now.Add(2*time.Second), enqueue(eventQueue))
+	eventQueue.Enqueue(cancelled.Event())
+	eventQueue.Enqueue(rescheduled.Event())
+	eventQueue.Enqueue(NewFixedEvent(noop, now.Add(3*time.Second)))
+	cancelled.Cancel()
+	rescheduled.Reschedule(now.Add(time.Hour))
+	assert.True(t, cancelled.Cancelled())
+	assert.False(t, rescheduled.Cancelled())
+	assert.Equal(t, []time.Time{now.Add(3 * time.Second), now.Add(time.Hour)}, drain(eventQueue))
+}
+
+func TestCancelFiredHandle(t *testing.T) {
+	now := time.Now()
+	eventQueue := NewEventQueue(TestBucketSize, TestBucketsLimit)
+	handle := NewHandle(func(t time.Time) []Event {
+		return nil
+	}, now, enqueue(eventQueue))
+	eventQueue.Enqueue(handle.Event())
+	assert.Equal(t, []time.Time{now}, drain(eventQueue))
+	handle.Cancel()
+	assert.False(t, handle.Cancelled())
+	handle.Reschedule(now.Add(time.Second))
+	handle.Cancel()
+	assert.True(t, handle.Cancelled())
+	assert.Empty(t, drain(eventQueue))
+}
+
+func TestRepeatHandle(t *testing.T) {
+	now := time.Now()
+	eventQueue := NewEventQueue(TestBucketSize, TestBucketsLimit)
+	count := 0
+	var handle *Handle
+	handle = NewRepeatHandle(func(t time.Time) ([]Event, time.Duration) {
+		count++
+		if count == 5 {
+			handle.Cancel()
+		}
+		return nil, time.Second
+	}, now, enqueue(eventQueue))
+	eventQueue.Enqueue(handle.Event())
+	result := drain(eventQueue)
+	assert.Equal(t, 5, count)
+	assert.Equal(t, 5, len(result))
+	assert.True(t, handle.Cancelled())
+}
@@ -0,0 +1,154 @@
+package base
+
+import (
+	"time"
+)
+
+const (
+	// ladderThreshold is the max count of events in a bucket moved to bottom, larger buckets are split into a new rung
+	ladderThreshold = 50
+	// ladderMaxRungs limits the count of rungs
+	ladderMaxRungs = 8
+)
+
+// rung of a ladder, buckets before current are all dequeued
+type rung struct {
+	start   time.Time
+	width   time.Duration
+	buckets [][]item
+	current int
+}
+
+// currentStart return the start time of the current bucket
+func (r *rung) currentStart() time.Time {
+	return r.start.Add(r.width * time.Duration(r.current))
+}
+
+// LadderQueue is an EventScheduler implemented by a ladder queue
+// events far away are kept unsorted in top, and spread into rungs of buckets once needed,
+// buckets with too many events are split into a new rung with finer buckets, until small enough to be sorted in bottom,
+// so that enqueue/dequeue is O(1) amortized, and no tuning is needed
+type LadderQueue struct {
+	top      []item
+	topMin   time.Time
+	topMax   time.Time
+	topStart time.Time // events not before it are put into top
+	rungs    []*rung
+	bottom   bucket
+	total    int
+	sequence uint64
+}
+
+// NewLadderQueue create an empty LadderQueue
+func NewLadderQueue() *LadderQueue {
+	return &LadderQueue{}
+}
+
+func (q *LadderQueue) Enqueue(event Event) {
+	q.insert(newItem(event, q.sequence))
+	q.sequence++
+	q.total++
+}
+
+func (q *LadderQueue) insert(i item) {
+	t := i.event.Time()
+	if (len(q.rungs) == 0 && q.bottom.IsEmpty()) || !t.Before(q.topStart) {
+		if len(q.top) == 0 || t.Before(q.topMin) {
+			q.topMin = t
+		}
+		if len(q.top) == 0 || t.After(q.topMax) {
+			q.topMax = t
+		}
+		q.top = append(q.top, i)
+		return
+	}
+	for _, r := range q.rungs {
+		if !t.Before(r.currentStart()) {
+			index := int(t.Sub(r.start) / r.width)
+			r.buckets[index] = append(r.buckets[index], i)
+			return
+		}
+	}
+	q.bottom.push(i)
+}
+
+// spread the given items starting from the given time into a new rung
+func (q *LadderQueue) spread(items []item, start time.Time, span time.Duration) *rung {
+	width := span/time.Duration(len(items)) + 1
+	r := &rung{start: start, width: width, buckets: make([][]item, int(span/width)+1)}
+	for _, i := range items {
+		index := int(i.event.Time().Sub(start) / width)
+		r.buckets[index] = append(r.buckets[index], i)
+	}
+	q.rungs = append(q.rungs, r)
+	return r
+}
+
+// refill the bottom with the first bucket of events, return false if no events remain
+func (q *LadderQueue) refill() bool {
+	for q.bottom.IsEmpty() {
+		if len(q.rungs) == 0 {
+			if len(q.top) == 0 {
+				return false
+			}
+			r := q.spread(q.top, q.topMin, q.topMax.Sub(q.topMin))
+			q.topStart = r.start.Add(r.width * time.Duration(len(r.buckets)))
+			q.top = q.top[:0:0]
+			continue
+		}
+		r := q.rungs[len(q.rungs)-1]
+		for r.current < len(r.buckets) && len(r.buckets[r.current]) == 0 {
+			r.current++
+		}
+		if r.current >= len(r.buckets) {
+			q.rungs = q.rungs[:len(q.rungs)-1]
+			continue
+		}
+		items := r.buckets[r.current]
+		start := r.currentStart()
+		r.buckets[r.current] = nil
+		r.current++
+		if len(items) > ladderThreshold && r.width > 1 && len(q.rungs) < ladderMaxRungs {
+			q.spread(items, start, r.width-1)
+			continue
+		}
+		for _, i := range items {
+			q.bottom.push(i)
+		}
+	}
+	return true
+}
+
+// purge cancelled events, and make sure the first event is in the bottom if any
+func (q *LadderQueue) purge() {
+	for q.refill() && q.bottom.Peek().cancelled() {
+		q.bottom.pop()
+		q.total--
+	}
+}
+
+func (q *LadderQueue) Dequeue() (Event, bool) {
+	q.purge()
+	if q.bottom.IsEmpty() {
+		return nil, false
+	}
+	q.total--
+	return q.bottom.pop().event, true
+}
+
+func (q *LadderQueue) Peek() (Event, bool) {
+	q.purge()
+	if q.bottom.IsEmpty() {
+		return nil, false
+	}
+	return q.bottom.Peek().event, true
+}
+
+func (q *LadderQueue) Length() int {
+	return q.total
+}
+
+func (q *LadderQueue) IsEmpty() bool {
+	q.purge()
+	return q.bottom.IsEmpty()
+}
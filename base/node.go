@@ -1,11 +1,14 @@
 package base
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // Node indicates a simulated node in the network
 type Node interface {
 	// Check whether the node can work correctly, usually called by network just before the simulation
-	Check()
+	Check() error
 	// GetNext nodes of the node
 	GetNext() []Node
 	// SetNext nodes of the node, should not be used during simulation
@@ -20,5 +23,54 @@ type Node interface {
 	SetTransferCallback(callback TransferCallback)
 }
 
+// Delayer is implemented by nodes which delay packets before transferring them to next nodes
+type Delayer interface {
+	// MinDelay return the lower bound of delay of any packet through the node, false if unknown
+	MinDelay() (time.Duration, bool)
+}
+
+// Composite is implemented by nodes made of internal nodes, which transfer packets within the simulation but are not
+// nodes of the network, such as node.SubnetNode
+type Composite interface {
+	// Members return internal nodes of the node
+	Members() []Node
+}
+
 // TransferCallback called when a packet is transferred
 type TransferCallback func(packet Packet, source, target Node, now time.Time)
+
+// TransferError indicates a panic raised when a node transfers a packet
+type TransferError struct {
+	// Node where the panic raised
+	Node Node
+	// Packet being transferred
+	Packet Packet
+	// Value recovered from the panic
+	Value interface{}
+	// Stack where the panic raised
+	Stack []byte
+}
+
+func (e *TransferError) Error() string {
+	return fmt.Sprintf("panic in node %T: %v", e.Node, e.Value)
+}
+
+// Decision taken by a node when transferring a packet, such as whether the packet lost and how long delayed
+type Decision struct {
+	// Lost whether the packet lost
+	Lost bool
+	// Delay of the packet
+	Delay time.Duration
+	// Route is the index of the next node chosen, -1 if none
+	Route int
+}
+
+// DecisionHook is called when a node takes a decision on a packet, decide draws the decision as usual
+// return the decision actually taken by the node, so that decisions can be recorded or forced
+type DecisionHook func(node Node, packet Packet, decide func() Decision) Decision
+
+// Decider is implemented by nodes taking decisions on packets, usually randomly
+type Decider interface {
+	// SetDecisionHook set the hook of decisions, nil to remove, should not be used during simulation
+	SetDecisionHook(hook DecisionHook)
+}
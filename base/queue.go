@@ -63,12 +63,12 @@ func (q *Queue) Dequeue() interface{} {
 	if q.head == q.tail {
 		panic("queue is empty")
 	}
-	if q.head >= q.length {
-		q.head = 0
-	}
 	result := q.storage[q.head]
 	q.storage[q.head] = nil
 	q.head++
+	if q.head >= q.length {
+		q.head = 0
+	}
 	return result
 }
 
@@ -86,10 +86,11 @@ func (q *Queue) At(index int) interface{} {
 
 // Do iterate the queue with the given action
 func (q *Queue) Do(action func(interface{})) {
-	for i := q.head; i != q.tail; i++ {
+	for i := q.head; i != q.tail; {
+		action(q.storage[i])
+		i++
 		if i >= q.length {
-			i = -1
+			i = 0
 		}
-		action(q.storage[i])
 	}
 }
@@ -38,3 +38,27 @@ func TestQueueOverflow(t *testing.T) {
 		it++
 	}
 }
+
+func TestQueueWrap(t *testing.T) {
+	ring := NewQueue(4)
+	next, expected := 0, 0
+	for round := 0; round < 100; round++ {
+		for i := 0; i < round%7+1; i++ {
+			ring.Enqueue(next)
+			next++
+		}
+		for i := 0; i < round%5+1 && !ring.IsEmpty(); i++ {
+			assert.Equal(t, expected, ring.Dequeue())
+			expected++
+		}
+		assert.Equal(t, next-expected, ring.Length())
+		var all []interface{}
+		ring.Do(func(x interface{}) {
+			all = append(all, x)
+		})
+		assert.Equal(t, ring.Length(), len(all))
+		for i, x := range all {
+			assert.Equal(t, ring.At(i), x)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package base
+
+// EventScheduler sorts events according to the time of events
+// events at the same time point must be sorted by priority of Prioritized events and then the order of enqueue,
+// so that the order is deterministic
+// Cancellable events cancelled are never dequeued, and may be removed lazily
+type EventScheduler interface {
+	// Enqueue the given event
+	Enqueue(event Event)
+	// Dequeue the first event, false if empty
+	Dequeue() (Event, bool)
+	// Peek the first event without removing it, false if empty
+	Peek() (Event, bool)
+	// Length of the scheduler, may include cancelled events not removed yet
+	Length() int
+	// IsEmpty whether no events remain
+	IsEmpty() bool
+}
+
+// cancelled whether the event of the item is cancelled
+func (i item) cancelled() bool {
+	c, ok := i.event.(Cancellable)
+	return ok && c.Cancelled()
+}
+
+// HeapScheduler is an EventScheduler implemented by a single binary heap
+// O(log n) time complexity for enqueue/dequeue, no matter how events distributed
+type HeapScheduler struct {
+	heap     bucket
+	sequence uint64
+}
+
+// NewHeapScheduler create an empty HeapScheduler
+func NewHeapScheduler() *HeapScheduler {
+	return &HeapScheduler{}
+}
+
+func (s *HeapScheduler) Enqueue(event Event) {
+	s.heap.push(newItem(event, s.sequence))
+	s.sequence++
+}
+
+func (s *HeapScheduler) Dequeue() (Event, bool) {
+	s.purge()
+	if s.heap.IsEmpty() {
+		return nil, false
+	}
+	return s.heap.pop().event, true
+}
+
+func (s *HeapScheduler) Peek() (Event, bool) {
+	s.purge()
+	if s.heap.IsEmpty() {
+		return nil, false
+	}
+	return s.heap.Peek().event, true
+}
+
+// purge cancelled events at the head of the heap
+func (s *HeapScheduler) purge() {
+	for !s.heap.IsEmpty() && s.heap.Peek().cancelled() {
+		s.heap.pop()
+	}
+}
+
+func (s *HeapScheduler) Length() int {
+	return s.heap.Len()
+}
+
+func (s *HeapScheduler) IsEmpty() bool {
+	s.purge()
+	return s.heap.IsEmpty()
+}
@@ -0,0 +1,181 @@
+package base
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+// dequeue the first event of the scheduler, nil if empty
+func dequeue(scheduler EventScheduler) Event {
+	event, _ := scheduler.Dequeue()
+	return event
+}
+
+// peek the first event of the scheduler, nil if empty
+func peek(scheduler EventScheduler) Event {
+	event, _ := scheduler.Peek()
+	return event
+}
+
+// schedulers to be tested and benchmarked
+var schedulers = []struct {
+	name string
+	new  func() EventScheduler
+}{
+	{"heap", func() EventScheduler { return NewHeapScheduler() }},
+	{"calendar", func() EventScheduler { return NewEventQueue(TestBucketSize, TestBucketsLimit) }},
+	{"adaptive", func() EventScheduler { return NewAdaptiveEventQueue() }},
+	{"wheel", func() EventScheduler { return NewTimingWheel(time.Microsecond, 8, 4) }},
+	{"ladder", func() EventScheduler { return NewLadderQueue() }},
+}
+
+// workloads of delays, used for both tests and benchmarks
+var workloads = []struct {
+	name  string
+	delay func(random *rand.Rand) time.Duration
+}{
+	{"mixed", mixedDelay},
+	{"timers", func(random *rand.Rand) time.Duration {
+		return time.Duration(random.Int63n(int64(10 * time.Second)))
+	}},
+	{"same", func(random *rand.Rand) time.Duration {
+		return time.Duration(random.Intn(4)) * time.Millisecond
+	}},
+	{"far", func(random *rand.Rand) time.Duration {
+		return time.Duration(random.Int63n(int64(24 * time.Hour)))
+	}},
+}
+
+func TestSchedulerOrder(t *testing.T) {
+	now := time.Now()
+	for _, s := range schedulers {
+		for _, w := range workloads {
+			random := rand.New(rand.NewSource(0))
+			scheduler := s.new()
+			events := make([]Event, 5000)
+			for i := range events {
+				events[i] = NewFixedEvent(nil, now.Add(w.delay(random)))
+				scheduler.Enqueue(events[i])
+			}
+			assert.Equal(t, len(events), scheduler.Length(), s.name)
+			sort.SliceStable(events, func(i, j int) bool {
+				return events[i].Time().Before(events[j].Time())
+			})
+			for _, e := range events {
+				assert.Same(t, e, peek(scheduler), s.name+" "+w.name)
+				assert.Same(t, e, dequeue(scheduler), s.name+" "+w.name)
+			}
+			assert.True(t, scheduler.IsEmpty(), s.name)
+			_, ok := scheduler.Dequeue()
+			assert.False(t, ok, s.name)
+			_, ok = scheduler.Peek()
+			assert.False(t, ok, s.name)
+		}
+	}
+}
+
+func TestSchedulerHold(t *testing.T) {
+	now := time.Now()
+	for _, w := range workloads {
+		var expected []Event
+		for _, s := range schedulers {
+			random := rand.New(rand.NewSource(0))
+			scheduler := s.new()
+			for i := 0; i < 1000; i++ {
+				scheduler.Enqueue(NewFixedEvent(nil, now.Add(w.delay(random))))
+			}
+			var result []Event
+			for i := 0; i < 20000; i++ {
+				e := dequeue(scheduler)
+				result = append(result, e)
+				for j := 0; j < random.Intn(3); j++ {
+					scheduler.Enqueue(NewFixedEvent(nil, e.Time().Add(w.delay(random))))
+				}
+				if scheduler.IsEmpty() {
+					break
+				}
+			}
+			if expected == nil {
+				expected = result
+				continue
+			}
+			assert.Equal(t, len(expected), len(result), s.name+" "+w.name)
+			for i := range expected {
+				if !assert.Equal(t, expected[i].Time(), result[i].Time(), s.name+" "+w.name) {
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestSchedulerPriority(t *testing.T) {
+	now := time.Now()
+	for _, s := range schedulers {
+		scheduler := s.new()
+		var data, control []Event
+		for i := 0; i < 10; i++ {
+			data = append(data, NewFixedEvent(nil, now))
+			scheduler.Enqueue(data[i])
+			control = append(control, NewFixedEventWithPriority(nil, now, PriorityControl))
+			scheduler.Enqueue(control[i])
+		}
+		late := NewDelayedEventWithPriority(nil, time.Second, now, PriorityControl)
+		scheduler.Enqueue(late)
+		for _, e := range append(append(control, data...), late) {
+			assert.Same(t, e, dequeue(scheduler), s.name)
+		}
+	}
+}
+
+func TestSchedulerCancel(t *testing.T) {
+	now := time.Now()
+	for _, s := range schedulers {
+		scheduler := s.new()
+		schedule := func(events ...Event) {
+			for _, event := range events {
+				scheduler.Enqueue(event)
+			}
+		}
+		var handles []*Handle
+		for i := 0; i < 100; i++ {
+			handle := NewHandle(func(t time.Time) []Event { return nil }, now.Add(time.Duration(i)*time.Millisecond), schedule)
+			handles = append(handles, handle)
+			schedule(handle.Event())
+		}
+		for i := 0; i < 100; i += 2 {
+			handles[i].Cancel()
+		}
+		handles[1].Reschedule(now.Add(time.Second))
+		var times []time.Time
+		for !scheduler.IsEmpty() {
+			times = append(times, dequeue(scheduler).Time())
+		}
+		assert.Equal(t, 50, len(times), s.name)
+		assert.Equal(t, now.Add(3*time.Millisecond), times[0], s.name)
+		assert.Equal(t, now.Add(time.Second), times[len(times)-1], s.name)
+	}
+}
+
+func BenchmarkScheduler(b *testing.B) {
+	now := time.Now()
+	for _, w := range workloads {
+		for _, s := range schedulers {
+			b.Run(w.name+"/"+s.name, func(b *testing.B) {
+				random := rand.New(rand.NewSource(0))
+				scheduler := s.new()
+				for i := 0; i < 10000; i++ {
+					scheduler.Enqueue(NewFixedEvent(nil, now.Add(w.delay(random))))
+				}
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					e := dequeue(scheduler)
+					scheduler.Enqueue(NewFixedEvent(nil, e.Time().Add(w.delay(random))))
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package base
+
+import (
+	"time"
+)
+
+// TimingWheel is an EventScheduler implemented by a hierarchical timing wheel
+// time is divided into ticks, each level of wheels has the same count of slots, and each slot of a level covers all slots
+// of the level below, events are put into the slot of the lowest level covering them, and cascaded to lower levels
+// once the time goes, so that enqueue is O(1), usually better with lots of timers in a long period
+// events within a tick are sorted by a heap once the tick comes, events beyond all levels are kept in a heap as well
+type TimingWheel struct {
+	tick     time.Duration
+	bits     uint // slots of each level is 1 << bits
+	mask     int64
+	levels   [][][]item
+	counts   []int // count of events in each level
+	origin   time.Time
+	current  int64  // index of the current tick since origin, events not after the current tick are ready
+	ready    bucket // events ready, sorted by time, priority and sequence
+	overflow bucket // events beyond all levels
+	total    int
+	sequence uint64
+	started  bool
+}
+
+// NewTimingWheel create an empty TimingWheel with the given tick, 1 << bits slots in each level, and count of levels
+// tick * (1 << (bits * levels)) should cover most of the events, events beyond are sorted by a heap
+func NewTimingWheel(tick time.Duration, bits uint, levels int) *TimingWheel {
+	if tick <= 0 || bits == 0 || levels <= 0 || bits*uint(levels) >= 63 {
+		panic("invalid timing wheel parameters")
+	}
+	w := &TimingWheel{
+		tick:   tick,
+		bits:   bits,
+		mask:   1<<bits - 1,
+		levels: make([][][]item, levels),
+		counts: make([]int, levels),
+	}
+	for i := range w.levels {
+		w.levels[i] = make([][]item, 1<<bits)
+	}
+	return w
+}
+
+// ticks return the index of tick of the given time since origin
+func (w *TimingWheel) ticks(t time.Time) int64 {
+	return int64(t.Sub(w.origin) / w.tick)
+}
+
+func (w *TimingWheel) Enqueue(event Event) {
+	if !w.started {
+		w.origin, w.started = event.Time(), true
+	}
+	w.insert(newItem(event, w.sequence))
+	w.sequence++
+	w.total++
+}
+
+// insert the item into the ready heap or the lowest level of wheels covering it
+func (w *TimingWheel) insert(i item) {
+	t := w.ticks(i.event.Time())
+	if t <= w.current {
+		w.ready.push(i)
+		return
+	}
+	for level := range w.levels {
+		shift := w.bits * uint(level+1)
+		if t>>shift == w.current>>shift {
+			slot := (t >> (w.bits * uint(level))) & w.mask
+			w.levels[level][slot] = append(w.levels[level][slot], i)
+			w.counts[level]++
+			return
+		}
+	}
+	w.overflow.push(i)
+}
+
+// advance the current tick to the next tick with events, and move events of the tick to the ready heap
+// return false if no events remain in wheels
+func (w *TimingWheel) advance() bool {
+	for level := range w.levels {
+		if w.counts[level] == 0 {
+			continue
+		}
+		shift := w.bits * uint(level)
+		for slot := (w.current>>shift)&w.mask + 1; slot <= w.mask; slot++ {
+			items := w.levels[level][slot]
+			if len(items) == 0 {
+				continue
+			}
+			// jump to the first tick covered by the slot, nothing remains before it
+			w.current = (w.current>>shift&^w.mask | slot) << shift
+			w.levels[level][slot] = items[:0:0]
+			w.counts[level] -= len(items)
+			for _, i := range items {
+				w.insert(i)
+			}
+			return true
+		}
+	}
+	if w.overflow.IsEmpty() {
+		return false
+	}
+	w.current = w.ticks(w.overflow.Peek().event.Time())
+	top := w.bits * uint(len(w.levels))
+	for !w.overflow.IsEmpty() && w.ticks(w.overflow.Peek().event.Time())>>top == w.current>>top {
+		w.insert(w.overflow.pop())
+	}
+	return true
+}
+
+// purge cancelled events, and make sure the first event is in the ready heap if any
+func (w *TimingWheel) purge() {
+	for {
+		for !w.ready.IsEmpty() && w.ready.Peek().cancelled() {
+			w.ready.pop()
+			w.total--
+		}
+		if !w.ready.IsEmpty() || !w.advance() {
+			return
+		}
+	}
+}
+
+func (w *TimingWheel) Dequeue() (Event, bool) {
+	w.purge()
+	if w.ready.IsEmpty() {
+		return nil, false
+	}
+	w.total--
+	return w.ready.pop().event, true
+}
+
+func (w *TimingWheel) Peek() (Event, bool) {
+	w.purge()
+	if w.ready.IsEmpty() {
+		return nil, false
+	}
+	return w.ready.Peek().event, true
+}
+
+func (w *TimingWheel) Length() int {
+	return w.total
+}
+
+func (w *TimingWheel) IsEmpty() bool {
+	w.purge()
+	return w.ready.IsEmpty()
+}
@@ -0,0 +1,50 @@
+package ns_x
+
+import (
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/math"
+	"github.com/bytedance/ns-x/v2/node"
+	"github.com/bytedance/ns-x/v2/tick"
+	"testing"
+	"time"
+)
+
+// BenchmarkTransfer measures events handled per second, with packets passing a chain of nodes
+func BenchmarkTransfer(b *testing.B) {
+	now := time.Now()
+	builder := NewBuilder().Chain().NodeWithName("sender", node.NewEndpointNode())
+	for i := 0; i < 8; i++ {
+		builder.
+			Node(node.NewChannelNode(node.WithDelay(math.NewFixedDelay(time.Millisecond)))).
+			Node(node.NewGatherNode())
+	}
+	network, nodes, err := builder.NodeWithName("receiver", node.NewEndpointNode()).Build()
+	if err != nil {
+		b.Fatal(err)
+	}
+	sender := nodes["sender"].(*node.EndpointNode)
+	received := 0
+	nodes["receiver"].(*node.EndpointNode).Receive(func(packet base.Packet, now time.Time) []base.Event {
+		received++
+		return nil
+	})
+	packet := base.RawPacket{}
+	events := make([]base.Event, 0, b.N)
+	for i := 0; i < b.N; i++ {
+		events = append(events, sender.Send(packet, now.Add(time.Duration(i)*time.Microsecond)))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	start := time.Now()
+	if err := network.Run(events, tick.NewStepClock(now, time.Millisecond), time.Hour, WithVirtualTime()); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := network.Wait(); err != nil {
+		b.Fatal(err)
+	}
+	if received != b.N {
+		b.Fatalf("%d packets received, expect %d", received, b.N)
+	}
+	// each packet is sent, passes 16 nodes, and then received
+	b.ReportMetric(float64(b.N*18)/time.Since(start).Seconds(), "events/s")
+}
@@ -1,8 +1,8 @@
 package ns_x
 
 import (
+	"errors"
 	"github.com/bytedance/ns-x/v2/base"
-	"reflect"
 	"strconv"
 	"strings"
 )
@@ -24,11 +24,30 @@ type Builder interface {
 	NodeOfName(name string) Builder
 	// GroupOfName find the group with the given name, and then perform the Group operation on it
 	GroupOfName(name string) Builder
+	// Link describe a duplex link between nodes of the given names, each direction is a group of a RestrictNode if limited,
+	// and a ChannelNode, named after the direction like "a->b", "a->b/restrict" and "a->b/channel",
+	// the current chain is kept, so that the chain can be continued after the link
+	// return handles of nodes of both directions
+	Link(a, b string, spec LinkSpec) *Link
+	// Instantiate describe an instance of the template with the given name and parameters in a new chain,
+	// the current chain is kept, so that the chain can be continued after the instance
+	// nodes, groups and ports of the instance are named with the name of the instance as prefix, like "site1/uplink"
+	Instantiate(name string, template Template, params Params) Builder
 	// Summary print the structure of the network to standard output
 	Summary() Builder
+	// DOT export the structure of the network described so far as a Graphviz DOT graph
+	// nodes are labeled with names, types and key parameters of node.Describer, groups are drawn as clusters
+	DOT() string
+	// Mermaid same to DOT, but export as a Mermaid flowchart, groups are drawn as subgraphs
+	Mermaid() string
+	// Validate the network described so far, return all problems found at once as Errors of ValidationError, with names of nodes:
+	// built-in nodes with wrong count of next nodes, ScatterNode without routes, nodes unreachable from any endpoint,
+	// endpoints whose packets cannot reach any receiver, and cycles of nodes without delay which would loop forever
+	Validate() error
 	// Build actually connect the nodes with relation described before, any connection outside the builder will be overwritten
 	// parameters are used to configure the network, return the built network, and a map from name to named nodes
-	Build() (*Network, map[string]base.Node)
+	// return all errors found when describing the network and validating it, in which case nothing is built
+	Build() (*Network, map[string]base.Node, error)
 }
 
 type group struct {
@@ -41,7 +60,8 @@ type builder struct {
 	nodeToName  map[base.Node]string
 	nameToGroup map[string]*group
 	current     base.Node
-	connections map[base.Node]map[base.Node]interface{}
+	connections map[base.Node][]base.Node // next nodes of each node in the order described
+	errors      Errors
 }
 
 func NewBuilder() Builder {
@@ -50,7 +70,7 @@ func NewBuilder() Builder {
 		nameToNode:  map[string]base.Node{},
 		nodeToName:  map[base.Node]string{},
 		nameToGroup: map[string]*group{},
-		connections: map[base.Node]map[base.Node]interface{}{},
+		connections: map[base.Node][]base.Node{},
 	}
 }
 
@@ -65,12 +85,9 @@ func (b *builder) Node(node base.Node) Builder {
 
 func (b *builder) NodeWithName(name string, node base.Node) Builder {
 	if b.current != nil {
-		connection, ok := b.connections[b.current]
-		if !ok {
-			connection = map[base.Node]interface{}{}
-			b.connections[b.current] = connection
+		if !contains(b.connections[b.current], node) {
+			b.connections[b.current] = append(b.connections[b.current], node)
 		}
-		connection[node] = nil
 	}
 	if _, ok := b.nodeToID[node]; !ok {
 		b.nodeToID[node] = len(b.nodeToID)
@@ -91,47 +108,62 @@ func (b *builder) GroupWithName(name string, inName, outName string) Builder {
 	if name != "" {
 		b.nameToGroup[name] = &group{inName: inName, outName: outName}
 	}
-	in := b.requireNodeByName(inName)
-	out := b.requireNodeByName(outName)
+	in, inOK := b.requireNodeByName(inName)
+	out, outOK := b.requireNodeByName(outName)
+	if !inOK || !outOK {
+		return b
+	}
 	b.Node(in)
 	b.current = out
 	return b
 }
 
 func (b *builder) NodeOfName(name string) Builder {
-	return b.Node(b.requireNodeByName(name))
+	node, ok := b.requireNodeByName(name)
+	if !ok {
+		return b
+	}
+	return b.Node(node)
 }
 
 func (b *builder) GroupOfName(name string) Builder {
 	group, ok := b.nameToGroup[name]
 	if !ok {
-		panic("no group with name: " + name)
+		b.errors = append(b.errors, errors.New("no group with name "+name))
+		return b
 	}
 	return b.Group(group.inName, group.outName)
 }
 
 func (b *builder) Summary() Builder {
-	nodes := make([]base.Node, len(b.nodeToID))
 	println("network summary: ")
-	for node, index := range b.nodeToID {
-		nodes[index] = node
-	}
-	for index, node := range nodes {
+	for index, node := range b.ordered() {
 		println(b.toString(node, index))
 	}
 	println()
 	return b
 }
 
-func (b *builder) Build() (*Network, map[string]base.Node) {
-	nodes := make([]base.Node, len(b.nodeToID))
-	for node, index := range b.nodeToID {
-		nodes[index] = node
+func (b *builder) Build() (*Network, map[string]base.Node, error) {
+	if err := b.Validate(); err != nil {
+		return nil, nil, err
+	}
+	b.connect()
+	network := NewNetwork(b.ordered())
+	for name, node := range b.nameToNode {
+		network.names[name] = node
 	}
+	for node, name := range b.nodeToName {
+		network.nodeName[node] = name
+	}
+	return network, b.nameToNode, nil
+}
+
+// connect nodes with the connections described
+func (b *builder) connect() {
 	for node, connection := range b.connections {
-		node.SetNext(normalize(connection)...)
+		node.SetNext(connection...)
 	}
-	return NewNetwork(nodes), b.nameToNode
 }
 
 func (b *builder) toString(node base.Node, index int) string {
@@ -141,16 +173,11 @@ func (b *builder) toString(node base.Node, index int) string {
 	sb.WriteString(": {name: \"")
 	sb.WriteString(b.nodeToName[node])
 	sb.WriteString("\", type: ")
-	t := reflect.TypeOf(node)
-	if t.Kind() == reflect.Ptr {
-		sb.WriteString(t.Elem().Name())
-	} else {
-		sb.WriteString(t.Name())
-	}
+	sb.WriteString(typeName(node))
 	sb.WriteString(", next: [")
 	connection := b.connections[node]
 	next := make([]string, 0, len(connection))
-	for n := range connection {
+	for _, n := range connection {
 		next = append(next, strconv.Itoa(b.nodeToID[n]))
 	}
 	sb.WriteString(strings.Join(next, ","))
@@ -158,21 +185,25 @@ func (b *builder) toString(node base.Node, index int) string {
 	return sb.String()
 }
 
-func normalize(nodes map[base.Node]interface{}) []base.Node {
-	result := make([]base.Node, 0, len(nodes))
-	for node := range nodes {
-		result = append(result, node)
+func contains(nodes []base.Node, node base.Node) bool {
+	for _, n := range nodes {
+		if n == node {
+			return true
+		}
 	}
-	return result
+	return false
 }
 
-func (b *builder) requireNodeByName(name string) base.Node {
+// requireNodeByName find the node with the given name, record an error if not found
+func (b *builder) requireNodeByName(name string) (base.Node, bool) {
 	if name == "" {
-		panic("name cannot be empty string")
+		b.errors = append(b.errors, errors.New("name cannot be empty string"))
+		return nil, false
 	}
 	node, ok := b.nameToNode[name]
 	if !ok {
-		panic("no node with name " + name)
+		b.errors = append(b.errors, errors.New("no node with name "+name))
+		return nil, false
 	}
-	return node
+	return node, true
 }
@@ -0,0 +1,166 @@
+package ns_x
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bytedance/ns-x/v2/base"
+	"io"
+	"sort"
+	"time"
+)
+
+// CausalEvent is an event handled in the causality DAG, linked to the event whose action created it
+type CausalEvent struct {
+	// ID of the event, unique in the simulation
+	ID uint64 `json:"id"`
+	// Parent is the ID of the event created this event, 0 if scheduled from outside the simulation
+	Parent uint64 `json:"parent"`
+	// Time when the event is handled, in simulated clock
+	Time time.Time `json:"time"`
+	// Node bound to the event, as index in Network.Nodes, -1 if none, internal nodes of base.Composite as the composite node
+	Node int `json:"node"`
+	// Origin is the node whose action created the event, as index in Network.Nodes, -1 if none, see base.Originated
+	Origin int `json:"origin"`
+}
+
+// causality writes events handled as CausalEvent, one json object per line
+type causality struct {
+	writer  *bufio.Writer
+	encoder *json.Encoder
+	indexes map[base.Node]int
+	err     error
+}
+
+func newCausality(writer io.Writer, nodes []base.Node) *causality {
+	w := bufio.NewWriter(writer)
+	c := &causality{writer: w, encoder: json.NewEncoder(w), indexes: make(map[base.Node]int, len(nodes))}
+	for i, node := range nodes {
+		c.indexes[node] = i
+		// internal nodes are collapsed into the composite node
+		for _, member := range flatten([]base.Node{node})[1:] {
+			c.indexes[member] = i
+		}
+	}
+	return c
+}
+
+// index of the node in the network, -1 if none
+func (c *causality) index(node base.Node) int {
+	if index, ok := c.indexes[node]; ok {
+		return index
+	}
+	return -1
+}
+
+// write the event handled, events not Causal are ignored
+func (c *causality) write(event base.Event) error {
+	cause := base.CauseOf(event)
+	if cause == nil || c.err != nil {
+		return c.err
+	}
+	c.err = c.encoder.Encode(&CausalEvent{
+		ID:     cause.ID,
+		Parent: cause.Parent,
+		Time:   event.Time(),
+		Node:   c.index(event.Node()),
+		Origin: c.index(cause.Origin),
+	})
+	return c.err
+}
+
+func (c *causality) finish() error {
+	if c.err != nil {
+		return c.err
+	}
+	return c.writer.Flush()
+}
+
+// exportCausality set up the export of the causality DAG if required
+func (s *simulation) exportCausality() error {
+	if s.config.causality == nil {
+		return nil
+	}
+	if s.config.partitions > 1 {
+		return errors.New("causality is not supported in parallel")
+	}
+	s.causality = newCausality(s.config.causality, s.network.nodes)
+	return nil
+}
+
+// CausalGraph is the causality DAG of a simulation exported by WithCausality
+type CausalGraph struct {
+	events   map[uint64]*CausalEvent
+	children map[uint64][]uint64
+	roots    []uint64
+}
+
+// ReadCausality read the causality DAG exported by WithCausality
+func ReadCausality(reader io.Reader) (*CausalGraph, error) {
+	g := &CausalGraph{events: map[uint64]*CausalEvent{}, children: map[uint64][]uint64{}}
+	decoder := json.NewDecoder(reader)
+	for decoder.More() {
+		e := &CausalEvent{}
+		if err := decoder.Decode(e); err != nil {
+			return nil, err
+		}
+		if _, ok := g.events[e.ID]; ok {
+			return nil, fmt.Errorf("duplicated event %d", e.ID)
+		}
+		g.events[e.ID] = e
+		if e.Parent == 0 {
+			g.roots = append(g.roots, e.ID)
+		} else {
+			g.children[e.Parent] = append(g.children[e.Parent], e.ID)
+		}
+	}
+	return g, nil
+}
+
+// Event return the event of the given ID, nil if not found
+func (g *CausalGraph) Event(id uint64) *CausalEvent {
+	return g.events[id]
+}
+
+// Len return the count of events in the graph
+func (g *CausalGraph) Len() int {
+	return len(g.events)
+}
+
+// Roots return IDs of events scheduled from outside the simulation, in the order handled
+func (g *CausalGraph) Roots() []uint64 {
+	return g.roots
+}
+
+// Children return IDs of events created by the event of the given ID, in the order handled
+func (g *CausalGraph) Children(id uint64) []uint64 {
+	return g.children[id]
+}
+
+// Chain return the events causing the event of the given ID, from the root to the event itself, nil if not found
+// such as the send and every hop which produced a packet received
+func (g *CausalGraph) Chain(id uint64) []*CausalEvent {
+	var chain []*CausalEvent
+	for e := g.events[id]; e != nil; e = g.events[e.Parent] {
+		chain = append(chain, e)
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// Filter return events matching the given predicate, in the order of ID
+func (g *CausalGraph) Filter(predicate func(event *CausalEvent) bool) []*CausalEvent {
+	var result []*CausalEvent
+	for _, e := range g.events {
+		if predicate(e) {
+			result = append(result, e)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
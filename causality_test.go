@@ -0,0 +1,78 @@
+package ns_x
+
+import (
+	"bytes"
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/math"
+	"github.com/bytedance/ns-x/v2/node"
+	"github.com/bytedance/ns-x/v2/tick"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCausality(t *testing.T) {
+	now := time.Unix(0, 0)
+	network, nodes, err := NewBuilder().
+		Chain().
+		NodeWithName("sender", node.NewEndpointNode()).
+		NodeWithName("channel", node.NewChannelNode(node.WithDelay(math.NewFixedDelay(time.Millisecond)))).
+		NodeWithName("gather", node.NewGatherNode()).
+		NodeWithName("receiver", node.NewEndpointNode()).
+		Build()
+	assert.NoError(t, err)
+	index := func(name string) int {
+		for i, n := range network.Nodes() {
+			if n == nodes[name] {
+				return i
+			}
+		}
+		return -1
+	}
+	sender := nodes["sender"].(*node.EndpointNode)
+	var events []base.Event
+	for i := 0; i < 3; i++ {
+		events = append(events, sender.Send(base.RawPacket{byte(i)}, now.Add(time.Duration(i)*time.Second)))
+	}
+	// scheduled from outside, as well as events to run
+	handle := network.Schedule(now, func(t time.Time) []base.Event {
+		return nil
+	})
+	buffer := &bytes.Buffer{}
+	assert.NoError(t, network.Run(events, tick.NewStepClock(now, time.Millisecond), time.Minute, WithVirtualTime(), WithCausality(buffer)))
+	_, err = network.Wait()
+	assert.NoError(t, err)
+	for i, e := range events {
+		cause := base.CauseOf(e)
+		assert.Equal(t, uint64(i+1), cause.ID)
+		assert.Zero(t, cause.Parent)
+		assert.Nil(t, cause.Origin)
+	}
+	assert.Equal(t, uint64(4), base.CauseOf(handle.Event()).ID)
+
+	graph, err := ReadCausality(buffer)
+	assert.NoError(t, err)
+	// each packet is sent through the channel, delivered to the gather after the delay, and then received
+	assert.Equal(t, 3*3+1, graph.Len())
+	assert.Equal(t, []uint64{1, 4, 2, 3}, graph.Roots())
+	received := graph.Filter(func(event *CausalEvent) bool {
+		return event.Node == index("receiver")
+	})
+	assert.Equal(t, 3, len(received))
+	for i, e := range received {
+		chain := graph.Chain(e.ID)
+		assert.Equal(t, 3, len(chain))
+		assert.Equal(t, uint64(i+1), chain[0].ID)
+		assert.Equal(t, -1, chain[0].Origin)
+		assert.Equal(t, index("sender"), chain[0].Node)
+		// the transfer to the gather is created by the channel, while the parent event is bound to the sender
+		assert.Equal(t, index("channel"), chain[1].Origin)
+		assert.Equal(t, index("gather"), chain[1].Node)
+		assert.Equal(t, index("gather"), chain[2].Origin)
+		assert.True(t, now.Add(time.Duration(i)*time.Second+time.Millisecond).Equal(e.Time))
+		assert.Equal(t, []uint64{chain[1].ID}, graph.Children(chain[0].ID))
+	}
+	assert.Nil(t, graph.Chain(1000))
+
+	assert.Error(t, network.Run(nil, tick.NewStepClock(now, time.Millisecond), time.Minute, WithParallel(2), WithCausality(buffer)))
+}
@@ -1,6 +1,8 @@
 package ns_x
 
 import (
+	"github.com/bytedance/ns-x/v2/base"
+	"io"
 	"time"
 )
 
@@ -14,8 +16,18 @@ const DefaultMaxBuckets = 128
 type Config func(config *config)
 
 type config struct {
-	bucketSize time.Duration
-	maxBuckets int
+	bucketSize   time.Duration
+	maxBuckets   int
+	adaptive     bool
+	scheduler    func() base.EventScheduler
+	virtualTime  bool
+	keepAlive    bool
+	partitions   int
+	lagThreshold time.Duration
+	lagPolicy    LagPolicy
+	record       io.Writer
+	replay       io.Reader
+	causality    io.Writer
 }
 
 // WithBucketSize set the bucket size of each bucket, usually used with WithMaxBuckets
@@ -36,6 +48,91 @@ func WithMaxBuckets(maxBuckets int) Config {
 	}
 }
 
+// WithAdaptiveBuckets let the event queue tune the bucket size and max bucket count by itself during the simulation,
+// instead of WithBucketSize and WithMaxBuckets, useful when delays in the network vary a lot or are unknown
+func WithAdaptiveBuckets() Config {
+	return func(config *config) {
+		config.adaptive = true
+	}
+}
+
+// WithScheduler use the event scheduler created by the given function to sort events, instead of the default EventQueue
+// such as base.NewHeapScheduler, base.NewTimingWheel and base.NewLadderQueue, different workloads favour different ones,
+// for example, timing wheels are usually better with lots of timers, see benchmarks in base package for detail
+func WithScheduler(scheduler func() base.EventScheduler) Config {
+	return func(config *config) {
+		config.scheduler = scheduler
+	}
+}
+
+// WithVirtualTime run the simulation in pure virtual time
+// instead of following the clock, simulated time jumps straight to the time point of the next event,
+// so that the simulation finishes as fast as possible, the clock is only used to determine the start time
+func WithVirtualTime() Config {
+	return func(config *config) {
+		config.virtualTime = true
+	}
+}
+
+// WithKeepAlive keep the simulation running even if no events remain, until stopped or reach lifetime
+// usually used with Network.Inject, when events are generated outside the simulation
+func WithKeepAlive() Config {
+	return func(config *config) {
+		config.keepAlive = true
+	}
+}
+
+// WithLagThreshold set the threshold of lateness, and the policy applied once an event is handled later than it
+// lateness is measured against the clock read again before each event handled, see LagStats in RunResult for statistics
+// without a threshold, it's measured against the clock last read by the event loop, so that no extra reads of the clock are made,
+// which matters to clocks advancing on each read such as tick.NewStepClock
+func WithLagThreshold(threshold time.Duration, policy LagPolicy) Config {
+	return func(config *config) {
+		config.lagThreshold = threshold
+		config.lagPolicy = policy
+	}
+}
+
+// WithRecord record the trace of the simulation to the given writer in a compact binary format
+// the trace holds every event handled, with its time, scheduling node, and decisions taken by nodes on packets,
+// such as loss and delay of ChannelNode and route of ScatterNode, see WithReplay
+func WithRecord(writer io.Writer) Config {
+	return func(config *config) {
+		config.record = writer
+	}
+}
+
+// WithReplay replay the trace recorded by WithRecord, decisions of nodes are forced from the trace instead of drawn,
+// the simulation fails with a ReplayError once it diverges from the trace, such as the network is changed
+func WithReplay(reader io.Reader) Config {
+	return func(config *config) {
+		config.replay = reader
+	}
+}
+
+// WithCausality export the causality DAG of the simulation to the given writer, as a json object of CausalEvent per line
+// every event handled is exported with its ID, the ID of the event created it, and the node where it was created,
+// so that the chain of events producing a packet can be found by ReadCausality, not supported in parallel
+// IDs are assigned by the sequential simulation anyway, see base.Causal
+func WithCausality(writer io.Writer) Config {
+	return func(config *config) {
+		config.causality = writer
+	}
+}
+
+// WithParallel simulate the network in parallel with at most the given count of partitions, always in virtual time
+// the network is split where packets are always delayed, typically ChannelNode with a delay model of known lower bound,
+// and the minimum of such delays is used as lookahead, partitions are synchronized every lookahead of simulated time
+// events are handled in exactly the same order as the sequential simulation, so that results are identical
+// events injected and events not bound to any node created outside partitions are handled when all partitions are synchronized,
+// while events not bound to any node created by a partition are handled by that partition, so their actions should only touch it,
+// an event sent to another partition earlier than the lookahead allows fails the simulation, the debugger is not supported
+func WithParallel(partitions int) Config {
+	return func(config *config) {
+		config.partitions = partitions
+	}
+}
+
 func (c *config) apply(configs ...Config) {
 	for _, config := range configs {
 		config(c)
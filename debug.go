@@ -0,0 +1,221 @@
+package ns_x
+
+import (
+	"github.com/bytedance/ns-x/v2/base"
+	"go.uber.org/atomic"
+	"sync"
+	"time"
+)
+
+// Breakpoint decides whether to pause the simulation just before the given event handled
+type Breakpoint func(event base.Event) bool
+
+// debugger controls the event loop to pause, step and resume the simulation
+type debugger struct {
+	lock       *sync.Mutex
+	cond       *sync.Cond
+	active     *atomic.Bool // whether the event loop should call checkpoint, to keep the loop fast without debugging
+	running    bool
+	pausing    bool
+	paused     bool
+	steps      int // count of events to handle before pause, negative means not stepping
+	pauseAt    *time.Time
+	breakpoint Breakpoint
+	now        time.Time
+	next       base.Event
+}
+
+func newDebugger() *debugger {
+	lock := &sync.Mutex{}
+	return &debugger{
+		lock:   lock,
+		cond:   sync.NewCond(lock),
+		active: atomic.NewBool(false),
+		steps:  -1,
+	}
+}
+
+// refresh the active flag, must be called with lock held
+func (d *debugger) refresh() {
+	d.active.Store(d.pausing || d.steps >= 0 || d.pauseAt != nil || d.breakpoint != nil)
+}
+
+// start is called by the event loop when the simulation starts
+func (d *debugger) start() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.running = true
+	d.paused = false
+	d.refresh()
+}
+
+// finish is called by the event loop when the simulation finishes
+func (d *debugger) finish() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.running = false
+	d.paused = false
+	d.pausing = false
+	d.steps = -1
+	d.next = nil
+	d.refresh()
+	d.cond.Broadcast()
+}
+
+// wake the paused event loop, used when the simulation is stopped
+func (d *debugger) wake() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.cond.Broadcast()
+}
+
+// checkpoint is called by the event loop just before the given event handled, block if the simulation should pause
+// return whether the simulation paused
+func (d *debugger) checkpoint(now time.Time, event base.Event, stopped *atomic.Bool) bool {
+	d.lock.Lock()
+	breakpoint := d.breakpoint
+	d.lock.Unlock()
+	// call the breakpoint without lock, so that it's free to use the network
+	hit := breakpoint != nil && breakpoint(event)
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	pause := d.pausing || hit
+	if d.steps == 0 {
+		pause = true
+	} else if d.steps > 0 {
+		d.steps--
+	}
+	if d.pauseAt != nil && !event.Time().Before(*d.pauseAt) {
+		pause = true
+		d.pauseAt = nil
+	}
+	if !pause {
+		d.refresh()
+		return false
+	}
+	d.pausing = false
+	d.steps = -1
+	d.paused = true
+	d.now = now
+	d.next = event
+	d.cond.Broadcast()
+	for d.paused && !stopped.Load() {
+		d.cond.Wait()
+	}
+	d.paused = false
+	d.next = nil
+	d.refresh()
+	return true
+}
+
+// wait until the simulation paused or finished, return whether paused, must be called with lock held
+func (d *debugger) wait() bool {
+	for d.running && !d.paused {
+		d.cond.Wait()
+	}
+	return d.paused
+}
+
+// Pause the running simulation just before the next event handled, block until paused
+// return false if no simulation is running
+func (n *Network) Pause() bool {
+	d := n.debugger
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !d.running {
+		return false
+	}
+	if !d.paused {
+		d.pausing = true
+		d.refresh()
+	}
+	return d.wait()
+}
+
+// PauseAt pause the simulation just before the first event not before the given time point handled
+// only take effect once, returned immediately, use WaitPaused to wait until paused
+func (n *Network) PauseAt(t time.Time) {
+	d := n.debugger
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.pauseAt = &t
+	d.refresh()
+}
+
+// Break pause the simulation just before each event matching the given breakpoint handled
+// only one breakpoint can be set at the same time, set nil to remove the breakpoint
+// the breakpoint is called in the event loop, so it can inspect nodes safely
+func (n *Network) Break(breakpoint Breakpoint) {
+	d := n.debugger
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.breakpoint = breakpoint
+	d.refresh()
+}
+
+// WaitPaused block until the simulation paused, return false if the simulation finished or not running
+func (n *Network) WaitPaused() bool {
+	d := n.debugger
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.wait()
+}
+
+// Step handle the given count of events and then pause again, block until paused
+// if the simulation is running, it will be paused after the given count of events handled
+// return false if the simulation finished before that
+func (n *Network) Step(count int) bool {
+	d := n.debugger
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !d.running {
+		return false
+	}
+	if count < 0 {
+		count = 0
+	}
+	if d.paused {
+		if count == 0 {
+			return true
+		}
+		// the event paused at will be handled once resumed
+		d.steps = count - 1
+		d.paused = false
+		d.cond.Broadcast()
+	} else {
+		d.steps = count
+	}
+	d.refresh()
+	return d.wait()
+}
+
+// Resume the paused simulation, do nothing if not paused
+func (n *Network) Resume() {
+	d := n.debugger
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.pausing = false
+	d.steps = -1
+	if d.paused {
+		d.paused = false
+		d.cond.Broadcast()
+	}
+	d.refresh()
+}
+
+// Paused return whether the simulation is paused now
+func (n *Network) Paused() bool {
+	d := n.debugger
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.paused
+}
+
+// Current return the current time in simulated clock and the next event to handle, only valid when paused
+// nodes can be inspected safely when paused, since the event loop is blocked
+func (n *Network) Current() (time.Time, base.Event) {
+	d := n.debugger
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.now, d.next
+}
@@ -0,0 +1,96 @@
+package ns_x
+
+import (
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/tick"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func counter(now time.Time, count *int) base.Event {
+	return base.NewPeriodicEvent(func(t time.Time) []base.Event {
+		*count++
+		return nil
+	}, time.Second, now)
+}
+
+func TestPauseAtAndStep(t *testing.T) {
+	now := time.Now()
+	count := 0
+	network := NewNetwork(nil)
+	network.PauseAt(now.Add(10 * time.Second))
+	assert.NoError(t, network.Run([]base.Event{counter(now, &count)}, tick.NewStepClock(now, time.Millisecond), time.Minute, WithVirtualTime()))
+	assert.True(t, network.WaitPaused())
+	assert.Equal(t, 10, count)
+	current, next := network.Current()
+	assert.Equal(t, now.Add(10*time.Second), current)
+	assert.Equal(t, now.Add(10*time.Second), next.Time())
+	assert.True(t, network.Step(1))
+	assert.Equal(t, 11, count)
+	assert.True(t, network.Step(5))
+	assert.Equal(t, 16, count)
+	network.Resume()
+	network.Wait()
+	assert.False(t, network.Paused())
+	assert.Equal(t, 61, count)
+}
+
+func TestCancelPaused(t *testing.T) {
+	now := time.Now()
+	network := NewNetwork(nil)
+	fired := false
+	handle := network.Schedule(now.Add(10*time.Second), func(t time.Time) []base.Event {
+		fired = true
+		return nil
+	})
+	network.PauseAt(now.Add(10 * time.Second))
+	assert.NoError(t, network.Run(nil, tick.NewStepClock(now, time.Millisecond), time.Minute, WithVirtualTime()))
+	assert.True(t, network.WaitPaused())
+	_, next := network.Current()
+	assert.Same(t, handle.Event(), next)
+	handle.Cancel()
+	network.Resume()
+	_, err := network.Wait()
+	assert.NoError(t, err)
+	assert.False(t, fired)
+}
+
+func TestBreakpoint(t *testing.T) {
+	now := time.Now()
+	count := 0
+	network := NewNetwork(nil)
+	target := now.Add(20 * time.Second)
+	network.Break(func(event base.Event) bool {
+		return event.Time().Equal(target)
+	})
+	assert.NoError(t, network.Run([]base.Event{counter(now, &count)}, tick.NewStepClock(now, time.Millisecond), time.Minute, WithVirtualTime()))
+	assert.True(t, network.WaitPaused())
+	assert.Equal(t, 20, count)
+	network.Break(nil)
+	network.Stop()
+	result, err := network.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, Cancelled, result.Reason)
+	assert.Equal(t, 20, count)
+}
+
+func TestPauseRealClock(t *testing.T) {
+	now := time.Now()
+	count := 0
+	network := NewNetwork(nil)
+	assert.NoError(t, network.Run([]base.Event{base.NewPeriodicEvent(func(t time.Time) []base.Event {
+		count++
+		return nil
+	}, time.Millisecond, now)}, tick.NewRealClock(), time.Hour))
+	assert.True(t, network.Pause())
+	paused := count
+	time.Sleep(10 * time.Millisecond)
+	assert.True(t, network.Paused())
+	assert.Equal(t, paused, count)
+	assert.True(t, network.Step(3))
+	assert.Equal(t, paused+3, count)
+	network.Stop()
+	network.Wait()
+	assert.False(t, network.Pause())
+}
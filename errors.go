@@ -0,0 +1,59 @@
+package ns_x
+
+import (
+	"fmt"
+	"github.com/bytedance/ns-x/v2/base"
+	"strings"
+	"time"
+)
+
+// Errors is a collection of errors reported at once
+type Errors []error
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// EventError indicates a panic raised when handling an event
+type EventError struct {
+	// Event being handled
+	Event base.Event
+	// Node where the panic raised, nil if not raised in any node
+	Node base.Node
+	// Time of the event being handled in simulated clock
+	Time time.Time
+	// Value recovered from the panic
+	Value interface{}
+	// Stack where the panic raised
+	Stack []byte
+}
+
+func newEventError(event base.Event, value interface{}, stack []byte) *EventError {
+	err := &EventError{Event: event, Value: value, Stack: stack}
+	if event != nil {
+		err.Time = event.Time()
+	}
+	if e, ok := value.(*base.TransferError); ok {
+		err.Node, err.Value, err.Stack = e.Node, e.Value, e.Stack
+	}
+	return err
+}
+
+func (e *EventError) Error() string {
+	if e.Node != nil {
+		return fmt.Sprintf("panic at %s in node %T: %v", e.Time, e.Node, e.Value)
+	}
+	return fmt.Sprintf("panic at %s: %v", e.Time, e.Value)
+}
+
+// Unwrap return the value recovered if it's an error
+func (e *EventError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package ns_x
+
+import (
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/node"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ordered return nodes described in the order of id
+func (b *builder) ordered() []base.Node {
+	nodes := make([]base.Node, len(b.nodeToID))
+	for node, index := range b.nodeToID {
+		nodes[index] = node
+	}
+	return nodes
+}
+
+// typeName return the name of the type of the node, without the pointer
+func typeName(node base.Node) string {
+	t := reflect.TypeOf(node)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// label return lines of the label of the node: name or id if unnamed, type, and parameters if it's a node.Describer
+func (b *builder) label(n base.Node) []string {
+	name, ok := b.nodeToName[n]
+	if !ok {
+		name = "#" + strconv.Itoa(b.nodeToID[n])
+	}
+	result := []string{name, typeName(n)}
+	if describer, ok := n.(node.Describer); ok {
+		result = append(result, describer.Describe()...)
+	}
+	return result
+}
+
+// members return nodes of the group, which are nodes on any path from the in node to the out node
+func (b *builder) members(g *group) map[base.Node]bool {
+	in, out := b.nameToNode[g.inName], b.nameToNode[g.outName]
+	if in == nil || out == nil {
+		return nil
+	}
+	forward := map[base.Node]bool{}
+	var visit func(n base.Node)
+	visit = func(n base.Node) {
+		if forward[n] {
+			return
+		}
+		forward[n] = true
+		for _, next := range b.connections[n] {
+			visit(next)
+		}
+	}
+	visit(in)
+	previous := map[base.Node][]base.Node{}
+	for n, connection := range b.connections {
+		for _, next := range connection {
+			previous[next] = append(previous[next], n)
+		}
+	}
+	result := map[base.Node]bool{}
+	var back func(n base.Node)
+	back = func(n base.Node) {
+		if result[n] || !forward[n] {
+			return
+		}
+		result[n] = true
+		for _, p := range previous[n] {
+			back(p)
+		}
+	}
+	back(out)
+	return result
+}
+
+// clusters return names of groups and nodes in each group, ordered by name
+// a node belonging to multiple groups is put in the first one, since clusters cannot overlap
+func (b *builder) clusters() ([]string, map[string][]base.Node) {
+	names := make([]string, 0, len(b.nameToGroup))
+	for name := range b.nameToGroup {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	assigned := map[base.Node]bool{}
+	result := map[string][]base.Node{}
+	for _, name := range names {
+		members := b.members(b.nameToGroup[name])
+		for _, n := range b.ordered() {
+			if members[n] && !assigned[n] {
+				assigned[n] = true
+				result[name] = append(result[name], n)
+			}
+		}
+	}
+	return names, result
+}
+
+// export the graph with the given writers of nodes, edges and clusters, nodes not in any group are written first
+func (b *builder) export(writeNode func(id string, label []string, indent string), writeEdge func(from, to string), begin func(name string), end func()) {
+	id := func(n base.Node) string {
+		return "n" + strconv.Itoa(b.nodeToID[n])
+	}
+	names, clusters := b.clusters()
+	clustered := map[base.Node]bool{}
+	for _, members := range clusters {
+		for _, n := range members {
+			clustered[n] = true
+		}
+	}
+	nodes := b.ordered()
+	for _, n := range nodes {
+		if !clustered[n] {
+			writeNode(id(n), b.label(n), "    ")
+		}
+	}
+	for _, name := range names {
+		if len(clusters[name]) == 0 {
+			continue
+		}
+		begin(name)
+		for _, n := range clusters[name] {
+			writeNode(id(n), b.label(n), "        ")
+		}
+		end()
+	}
+	for _, n := range nodes {
+		for _, next := range b.connections[n] {
+			writeEdge(id(n), id(next))
+		}
+	}
+}
+
+func (b *builder) DOT() string {
+	sb := &strings.Builder{}
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	quote := func(s string) string {
+		return `"` + escape.Replace(s) + `"`
+	}
+	sb.WriteString("digraph network {\n    rankdir=LR;\n    node [shape=box];\n")
+	cluster := 0
+	b.export(func(id string, label []string, indent string) {
+		lines := make([]string, len(label))
+		for i, line := range label {
+			lines[i] = escape.Replace(line)
+		}
+		sb.WriteString(indent + id + ` [label="` + strings.Join(lines, `\n`) + "\"];\n")
+	}, func(from, to string) {
+		sb.WriteString("    " + from + " -> " + to + ";\n")
+	}, func(name string) {
+		sb.WriteString("    subgraph cluster_" + strconv.Itoa(cluster) + " {\n        label=" + quote(name) + ";\n")
+		cluster++
+	}, func() {
+		sb.WriteString("    }\n")
+	})
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func (b *builder) Mermaid() string {
+	sb := &strings.Builder{}
+	escape := strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;")
+	sb.WriteString("graph LR;\n")
+	cluster := 0
+	b.export(func(id string, label []string, indent string) {
+		lines := make([]string, len(label))
+		for i, line := range label {
+			lines[i] = escape.Replace(line)
+		}
+		sb.WriteString(indent + id + `["` + strings.Join(lines, "<br/>") + "\"];\n")
+	}, func(from, to string) {
+		sb.WriteString("    " + from + " --> " + to + ";\n")
+	}, func(name string) {
+		sb.WriteString("    subgraph g" + strconv.Itoa(cluster) + `["` + escape.Replace(name) + "\"]\n")
+		cluster++
+	}, func() {
+		sb.WriteString("    end\n")
+	})
+	return sb.String()
+}
@@ -0,0 +1,55 @@
+package ns_x
+
+import (
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/math"
+	"github.com/bytedance/ns-x/v2/node"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func exported() Builder {
+	return NewBuilder().
+		Chain().
+		NodeWithName("limit", node.NewRestrictNode(node.WithPPSLimit(1000, 100))).
+		NodeWithName("link", node.NewChannelNode(node.WithDescribedDelay(math.NewFixedDelayModel(10*time.Millisecond)), node.WithLoss(func(packet base.Packet) bool { return false }))).
+		Chain().
+		GroupWithName(`"wan"`, "limit", "link").
+		Chain().
+		NodeWithName("sender", node.NewEndpointNode()).
+		GroupOfName(`"wan"`).
+		Node(node.NewEndpointNode())
+}
+
+func TestDOT(t *testing.T) {
+	assert.Equal(t, `digraph network {
+    rankdir=LR;
+    node [shape=box];
+    n2 [label="sender\nEndpointNode"];
+    n3 [label="#3\nEndpointNode"];
+    subgraph cluster_0 {
+        label="\"wan\"";
+        n0 [label="limit\nRestrictNode\npps: 1000, queue 100 packets"];
+        n1 [label="link\nChannelNode\ndelay: fixed(10ms)\nloss: custom"];
+    }
+    n0 -> n1;
+    n1 -> n3;
+    n2 -> n0;
+}
+`, exported().DOT())
+}
+
+func TestMermaid(t *testing.T) {
+	assert.Equal(t, `graph LR;
+    n2["sender<br/>EndpointNode"];
+    n3["#3<br/>EndpointNode"];
+    subgraph g0["#quot;wan#quot;"]
+        n0["limit<br/>RestrictNode<br/>pps: 1000, queue 100 packets"];
+        n1["link<br/>ChannelNode<br/>delay: fixed(10ms)<br/>loss: custom"];
+    end
+    n0 --> n1;
+    n1 --> n3;
+    n2 --> n0;
+`, exported().Mermaid())
+}
@@ -5,4 +5,5 @@ go 1.16
 require (
 	github.com/stretchr/testify v1.7.0
 	go.uber.org/atomic v1.9.0
+	gopkg.in/yaml.v3 v3.0.1
 )
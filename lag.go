@@ -0,0 +1,133 @@
+package ns_x
+
+import (
+	"fmt"
+	"github.com/bytedance/ns-x/v2/base"
+	"time"
+)
+
+// LagPolicy decides what to do once the simulation falls behind the clock more than the threshold
+type LagPolicy int
+
+const (
+	// LagIgnore only count the events late
+	LagIgnore LagPolicy = iota
+	// LagWarn print a warning the first time the simulation falls behind
+	LagWarn
+	// LagAbort finish the simulation with a LagError
+	LagAbort
+	// LagVirtual switch the simulation to virtual time, so that the rest of the simulation is still correct in order
+	LagVirtual
+)
+
+func (p LagPolicy) String() string {
+	switch p {
+	case LagIgnore:
+		return "ignore"
+	case LagWarn:
+		return "warn"
+	case LagAbort:
+		return "abort"
+	case LagVirtual:
+		return "virtual"
+	default:
+		return "unknown"
+	}
+}
+
+// LagBounds are upper bounds of buckets in the histogram of lateness, the last bucket holds lateness not less than 1s
+var LagBounds = []time.Duration{
+	time.Microsecond,
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// LagStats is the statistics of lateness of events, which is how late an event is handled compared with its time point
+// only events handled following the clock are counted, events handled in virtual time are never late
+type LagStats struct {
+	// Count of events counted
+	Count int
+	// Late is the count of events later than the threshold
+	Late int
+	// Max lateness of events
+	Max time.Duration
+	// Total lateness of events
+	Total time.Duration
+	// Histogram of lateness, the i-th bucket counts lateness less than LagBounds[i], and not less than the previous bound
+	Histogram [8]int
+	// Virtual is the time point when the simulation switched to virtual time by LagVirtual, zero if never
+	Virtual time.Time
+}
+
+// Mean lateness of events, zero if no events counted
+func (s *LagStats) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+// record the lateness of an event, return whether it exceeds the threshold
+func (s *LagStats) record(lateness, threshold time.Duration) bool {
+	s.Count++
+	s.Total += lateness
+	if lateness > s.Max {
+		s.Max = lateness
+	}
+	index := len(LagBounds)
+	for i, bound := range LagBounds {
+		if lateness < bound {
+			index = i
+			break
+		}
+	}
+	s.Histogram[index]++
+	if threshold > 0 && lateness > threshold {
+		s.Late++
+		return true
+	}
+	return false
+}
+
+// LagError indicates the simulation falls behind the clock more than the threshold with LagAbort
+type LagError struct {
+	// Event handled late
+	Event base.Event
+	// Lateness of the event
+	Lateness time.Duration
+	// Threshold configured
+	Threshold time.Duration
+}
+
+func (e *LagError) Error() string {
+	return fmt.Sprintf("event at %s is handled %s late, exceeds threshold %s", e.Event.Time(), e.Lateness, e.Threshold)
+}
+
+// checkLag record the lateness of the given event and apply the policy
+func (s *simulation) checkLag(event base.Event) error {
+	if s.config.lagThreshold > 0 {
+		// refresh the clock, previous events of the same time point may take a while
+		s.now = s.clock().Add(-s.offset)
+	}
+	lateness := s.now.Sub(event.Time())
+	if !s.lag.record(lateness, s.config.lagThreshold) {
+		return nil
+	}
+	switch s.config.lagPolicy {
+	case LagWarn:
+		if s.lag.Late == 1 {
+			println("network falls behind the clock by", lateness.String(), "at", event.Time().String())
+		}
+	case LagAbort:
+		return &LagError{Event: event, Lateness: lateness, Threshold: s.config.lagThreshold}
+	case LagVirtual:
+		println("network falls behind the clock by", lateness.String(), ", switch to virtual time at", s.now.String())
+		s.lag.Virtual = s.now
+		s.config.virtualTime = true
+	}
+	return nil
+}
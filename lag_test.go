@@ -0,0 +1,115 @@
+package ns_x
+
+import (
+	"errors"
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/tick"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// slow return events, the first one blocks for a while, so that the others are handled late
+func slow(now time.Time, count *int) []base.Event {
+	events := []base.Event{base.NewFixedEvent(func(t time.Time) []base.Event {
+		*count++
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}, now)}
+	for i := 1; i <= 5; i++ {
+		events = append(events, base.NewFixedEvent(func(t time.Time) []base.Event {
+			*count++
+			return nil
+		}, now.Add(time.Duration(i)*time.Millisecond)))
+	}
+	return events
+}
+
+func TestLagStats(t *testing.T) {
+	now := time.Now()
+	count := 0
+	network := NewNetwork(nil)
+	assert.NoError(t, network.Run(slow(now, &count), tick.NewRealClock(), time.Second, WithLagThreshold(5*time.Millisecond, LagWarn)))
+	result, err := network.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, 6, count)
+	assert.Equal(t, 6, result.Lag.Count)
+	assert.GreaterOrEqual(t, result.Lag.Late, 5)
+	assert.GreaterOrEqual(t, result.Lag.Max, 15*time.Millisecond)
+	assert.LessOrEqual(t, result.Lag.Mean(), result.Lag.Max)
+	total := 0
+	for _, c := range result.Lag.Histogram {
+		total += c
+	}
+	assert.Equal(t, 6, total)
+	assert.True(t, result.Lag.Virtual.IsZero())
+}
+
+func TestLagSameTime(t *testing.T) {
+	now := time.Now()
+	network := NewNetwork(nil)
+	events := []base.Event{
+		base.NewFixedEvent(func(t time.Time) []base.Event {
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		}, now),
+		base.NewFixedEvent(func(t time.Time) []base.Event { return nil }, now),
+	}
+	assert.NoError(t, network.Run(events, tick.NewRealClock(), time.Second, WithLagThreshold(5*time.Millisecond, LagIgnore)))
+	result, err := network.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Lag.Count)
+	assert.Equal(t, 1, result.Lag.Late)
+	assert.GreaterOrEqual(t, result.Lag.Max, 20*time.Millisecond)
+}
+
+func TestLagAbort(t *testing.T) {
+	now := time.Now()
+	count := 0
+	network := NewNetwork(nil)
+	assert.NoError(t, network.Run(slow(now, &count), tick.NewRealClock(), time.Second, WithLagThreshold(5*time.Millisecond, LagAbort)))
+	result, err := network.Wait()
+	lagError := &LagError{}
+	assert.True(t, errors.As(err, &lagError))
+	assert.Equal(t, now.Add(time.Millisecond), lagError.Event.Time())
+	assert.Equal(t, Failed, result.Reason)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 5, len(result.Remaining))
+}
+
+func TestLagVirtual(t *testing.T) {
+	now := time.Now()
+	count := 0
+	network := NewNetwork(nil)
+	events := append(slow(now, &count), base.NewFixedEvent(func(t time.Time) []base.Event {
+		count++
+		return nil
+	}, now.Add(time.Hour)))
+	assert.NoError(t, network.Run(events, tick.NewRealClock(), 2*time.Hour, WithLagThreshold(5*time.Millisecond, LagVirtual)))
+	result, err := network.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, Drained, result.Reason)
+	assert.Equal(t, 7, count)
+	assert.False(t, result.Lag.Virtual.IsZero())
+	assert.Equal(t, 2, result.Lag.Count)
+	assert.Equal(t, now.Add(time.Hour), result.End)
+}
+
+func TestLagStepClock(t *testing.T) {
+	now := time.Now()
+	count := 0
+	network := NewNetwork(nil)
+	events := []base.Event{base.NewPeriodicEvent(func(t time.Time) []base.Event {
+		count++
+		var events []base.Event
+		for i := 0; i < 3; i++ {
+			events = append(events, base.NewFixedEvent(func(t time.Time) []base.Event { return nil }, t))
+		}
+		return events
+	}, time.Second, now)}
+	assert.NoError(t, network.Run(events, tick.NewStepClock(now, time.Second), 300*time.Second))
+	result, err := network.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, 301, count)
+	assert.Equal(t, 1204, result.Lag.Count)
+}
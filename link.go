@@ -0,0 +1,126 @@
+package ns_x
+
+import (
+	"github.com/bytedance/ns-x/v2/node"
+	"strconv"
+)
+
+// LinkDirection describes a direction of a link, limits not positive mean unlimited
+type LinkDirection struct {
+	// PPS limit in packets per second
+	PPS float64
+	// BPS limit in bytes per second, same to node.WithBPSLimit
+	BPS float64
+	// QueuePackets limits the count of packets queued once reaching the limits
+	QueuePackets int64
+	// QueueBytes limits the size of packets queued once reaching the limits
+	QueueBytes int64
+	// Delay creates the delay of packets for each direction built, nil if not delayed
+	Delay func() node.DescribedDelay
+	// Loss creates the loss of packets for each direction built, nil if not lost
+	Loss func() node.DescribedLoss
+	// Reorder creates the reorder of packets for each direction built, nil if not reordered
+	Reorder func() node.DescribedReorder
+}
+
+// LinkSpec describes a duplex link
+type LinkSpec struct {
+	LinkDirection
+	// Reverse describes the direction from b to a if asymmetric, nil if same to the direction from a to b,
+	// models are created for each direction anyway, so that stateful models of both directions are independent
+	Reverse *LinkDirection
+}
+
+// LinkHandle holds nodes of a direction of a link
+type LinkHandle struct {
+	// Name of the group of the direction, from the restrict node or the channel node if unlimited, to the channel node
+	Name string
+	// Restrict limits the direction, nil if unlimited
+	Restrict *node.RestrictNode
+	// Channel delays and losses packets of the direction
+	Channel *node.ChannelNode
+}
+
+// Link holds handles of both directions of a duplex link
+type Link struct {
+	// Forward is the direction from a to b
+	Forward *LinkHandle
+	// Backward is the direction from b to a
+	Backward *LinkHandle
+}
+
+func (b *builder) Link(from, to string, spec LinkSpec) *Link {
+	reverse := spec.LinkDirection
+	if spec.Reverse != nil {
+		reverse = *spec.Reverse
+	}
+	current := b.current
+	link := &Link{
+		Forward:  b.direction(from, to, spec.LinkDirection),
+		Backward: b.direction(to, from, reverse),
+	}
+	b.current = current
+	return link
+}
+
+// direction describe a direction of the link as a group between the given nodes, and return the handle of it
+// the current chain is reset, which is restored by Link
+func (b *builder) direction(from, to string, d LinkDirection) *LinkHandle {
+	name := b.unique(from + "->" + to)
+	h := &LinkHandle{Name: name}
+	var options []node.Option
+	if d.Delay != nil {
+		options = append(options, node.WithDescribedDelay(d.Delay()))
+	}
+	if d.Loss != nil {
+		options = append(options, node.WithDescribedLoss(d.Loss()))
+	}
+	if d.Reorder != nil {
+		options = append(options, node.WithDescribedReorder(d.Reorder()))
+	}
+	h.Channel = node.NewChannelNode(options...)
+	in := name + "/channel"
+	b.Chain()
+	if d.PPS > 0 || d.BPS > 0 {
+		options = options[:0]
+		if d.PPS > 0 {
+			options = append(options, node.WithPPSLimit(d.PPS, limit(d.QueuePackets)))
+		}
+		if d.BPS > 0 {
+			options = append(options, node.WithBPSLimit(d.BPS, limit(d.QueueBytes)))
+		}
+		h.Restrict = node.NewRestrictNode(options...)
+		in = name + "/restrict"
+		b.NodeWithName(in, h.Restrict)
+	}
+	b.NodeWithName(name+"/channel", h.Channel).
+		Chain().
+		GroupWithName(name, in, name+"/channel").
+		Chain().
+		NodeOfName(from).
+		GroupOfName(name).
+		NodeOfName(to).
+		Chain()
+	return h
+}
+
+// limit convert limits not positive to unlimited
+func limit(value int64) int64 {
+	if value <= 0 {
+		return -1
+	}
+	return value
+}
+
+// unique return the given name if neither a node nor a group named so, otherwise the name with the least suffix "#n"
+func (b *builder) unique(name string) string {
+	result := name
+	for i := 2; ; i++ {
+		_, node := b.nameToNode[result]
+		_, group := b.nameToGroup[result]
+		if !node && !group {
+			return result
+		}
+		result = name + "#" + strconv.Itoa(i)
+	}
+}
@@ -0,0 +1,111 @@
+package ns_x
+
+import (
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/math"
+	"github.com/bytedance/ns-x/v2/node"
+	"github.com/bytedance/ns-x/v2/tick"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixed create fixed delays of the given duration for each direction
+func fixed(delay time.Duration) func() node.DescribedDelay {
+	return func() node.DescribedDelay {
+		return math.NewFixedDelayModel(delay)
+	}
+}
+
+func TestLink(t *testing.T) {
+	builder := NewBuilder().
+		Chain().
+		NodeWithName("a", node.NewEndpointNode()).
+		Chain().
+		NodeWithName("b", node.NewEndpointNode())
+	link := builder.Link("a", "b", LinkSpec{
+		LinkDirection: LinkDirection{BPS: 1000, QueueBytes: 4000, Delay: fixed(150 * time.Millisecond)},
+		Reverse:       &LinkDirection{Delay: fixed(200 * time.Millisecond)},
+	})
+	network, nodes, err := builder.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, 5, len(network.Nodes()))
+	assert.Equal(t, "a->b", link.Forward.Name)
+	assert.Equal(t, "b->a", link.Backward.Name)
+	assert.Nil(t, link.Backward.Restrict)
+	assert.Same(t, link.Forward.Restrict, nodes["a->b/restrict"])
+	assert.Same(t, link.Forward.Channel, nodes["a->b/channel"])
+	assert.Same(t, link.Backward.Channel, nodes["b->a/channel"])
+	assert.Equal(t, []base.Node{link.Forward.Restrict}, nodes["a"].GetNext())
+	assert.Equal(t, []base.Node{link.Forward.Channel}, link.Forward.Restrict.GetNext())
+	assert.Equal(t, []base.Node{nodes["b"]}, link.Forward.Channel.GetNext())
+	assert.Equal(t, []base.Node{link.Backward.Channel}, nodes["b"].GetNext())
+	assert.Equal(t, []base.Node{nodes["a"]}, link.Backward.Channel.GetNext())
+
+	now := time.Unix(0, 0)
+	a, b := nodes["a"].(*node.EndpointNode), nodes["b"].(*node.EndpointNode)
+	var received []time.Time
+	b.Receive(func(packet base.Packet, now time.Time) []base.Event {
+		return base.Aggregate(b.Send(packet, now))
+	})
+	a.Receive(func(packet base.Packet, now time.Time) []base.Event {
+		received = append(received, now)
+		return nil
+	})
+	events := []base.Event{a.Send(make(base.RawPacket, 1000), now), a.Send(make(base.RawPacket, 1000), now)}
+	assert.NoError(t, network.Run(events, tick.NewStepClock(now, time.Millisecond), time.Minute, WithVirtualTime()))
+	_, err = network.Wait()
+	assert.NoError(t, err)
+	// the second packet is queued for a second by the bandwidth limit
+	assert.Equal(t, []time.Time{now.Add(350 * time.Millisecond), now.Add(1350 * time.Millisecond)}, received)
+}
+
+func TestLinkNames(t *testing.T) {
+	builder := NewBuilder().
+		Chain().
+		NodeWithName("a", node.NewGatherNode()).
+		Chain().
+		NodeWithName("b", node.NewGatherNode())
+	assert.Equal(t, "a->b", builder.Link("a", "b", LinkSpec{}).Forward.Name)
+	assert.Equal(t, "a->b#2", builder.Link("a", "b", LinkSpec{}).Forward.Name)
+	assert.Equal(t, "b->a#3", builder.Link("b", "a", LinkSpec{}).Forward.Name)
+	builder.Link("a", "c", LinkSpec{})
+	_, _, err := builder.Build()
+	assert.True(t, strings.HasPrefix(err.Error(), "no node with name c; no node with name c;"))
+}
+
+func TestLinkKeepChain(t *testing.T) {
+	builder := NewBuilder().
+		Chain().
+		NodeWithName("a", node.NewEndpointNode()).
+		Chain().
+		NodeWithName("b", node.NewEndpointNode()).
+		Chain().
+		NodeWithName("c", node.NewEndpointNode())
+	builder.Link("a", "b", LinkSpec{LinkDirection: LinkDirection{Delay: fixed(time.Millisecond)}})
+	builder.NodeWithName("d", node.NewEndpointNode())
+	_, nodes, err := builder.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, []base.Node{nodes["d"]}, nodes["c"].GetNext())
+}
+
+func TestLinkIndependentDirections(t *testing.T) {
+	builder := NewBuilder().
+		Chain().
+		NodeWithName("a", node.NewEndpointNode()).
+		Chain().
+		NodeWithName("b", node.NewEndpointNode())
+	// a gilbert loss starting in the bad state loses the first packet, and then turns good
+	loss := func() node.DescribedLoss {
+		return math.NewGilbertLossModel(0, 1, 0, 1, rand.New(rand.NewSource(0)))
+	}
+	link := builder.Link("a", "b", LinkSpec{LinkDirection: LinkDirection{Loss: loss}})
+	_, _, err := builder.Build()
+	assert.NoError(t, err)
+	now := time.Now()
+	assert.Nil(t, link.Forward.Channel.Transfer(base.RawPacket{}, now))
+	assert.Nil(t, link.Backward.Channel.Transfer(base.RawPacket{}, now))
+	assert.Equal(t, 1, len(link.Forward.Channel.Transfer(base.RawPacket{}, now)))
+}
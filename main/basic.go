@@ -22,7 +22,7 @@ func basic() {
 	}
 	n1 := node.NewEndpointNode()
 	t := time.Now()
-	network, nodes := helper.
+	network, nodes, err := helper.
 		Chain().
 		NodeWithName("entry1", n1).
 		Node(node.NewChannelNode(node.WithTransferCallback(callback), node.WithLoss(math.NewRandomLoss(0.1, random)))).
@@ -34,6 +34,9 @@ func basic() {
 		NodeOfName("endpoint").
 		Summary().
 		Build()
+	if err != nil {
+		panic(err)
+	}
 	entry1 := nodes["entry1"].(*node.EndpointNode)
 	entry2 := nodes["entry2"].(*node.EndpointNode)
 	endpoint := nodes["endpoint"].(*node.EndpointNode)
@@ -61,6 +64,11 @@ func basic() {
 		return nil
 	}, time.Second, t)
 	events = append(events, event)
-	network.Run(events, tick.NewStepClock(t, time.Second), 300*time.Second)
-	defer network.Wait()
+	err = network.Run(events, tick.NewStepClock(t, time.Second), 300*time.Second)
+	if err != nil {
+		panic(err)
+	}
+	if _, err = network.Wait(); err != nil {
+		panic(err)
+	}
 }
@@ -13,24 +13,30 @@ import (
 
 func echo() {
 	now := time.Now()
-	helper := ns_x.NewBuilder()
-	network, nodes := helper.
-		Chain().
-		NodeWithName("restrict 1", node.NewRestrictNode(node.WithBPSLimit(1024*1024, 4*1024*1024))).
-		NodeWithName("channel 1", node.NewChannelNode(node.WithDelay(math.NewFixedDelay(150*time.Millisecond)))).
-		Chain().
-		NodeWithName("restrict 2", node.NewRestrictNode(node.WithPPSLimit(10, 50))).
-		NodeWithName("channel 2", node.NewChannelNode(node.WithDelay(math.NewFixedDelay(200*time.Millisecond)))).
+	helper := ns_x.NewBuilder().
 		Chain().
 		NodeWithName("endpoint 1", node.NewEndpointNode()).
-		Group("restrict 1", "channel 1").
-		NodeWithName("endpoint 2", node.NewEndpointNode()).
 		Chain().
-		NodeOfName("endpoint 2").
-		Group("restrict 2", "channel 2").
-		NodeOfName("endpoint 1").
+		NodeWithName("endpoint 2", node.NewEndpointNode())
+	// each direction is a restrict node and a channel node, grouped as "endpoint 1->endpoint 2" and "endpoint 2->endpoint 1"
+	helper.Link("endpoint 1", "endpoint 2", ns_x.LinkSpec{
+		LinkDirection: ns_x.LinkDirection{
+			BPS:        1024 * 1024,
+			QueueBytes: 4 * 1024 * 1024,
+			Delay:      func() node.DescribedDelay { return math.NewFixedDelayModel(150 * time.Millisecond) },
+		},
+		Reverse: &ns_x.LinkDirection{
+			PPS:          10,
+			QueuePackets: 50,
+			Delay:        func() node.DescribedDelay { return math.NewFixedDelayModel(200 * time.Millisecond) },
+		},
+	})
+	network, nodes, err := helper.
 		Summary().
 		Build()
+	if err != nil {
+		panic(err)
+	}
 	endpoint1 := nodes["endpoint 1"].(*node.EndpointNode)
 	endpoint2 := nodes["endpoint 2"].(*node.EndpointNode)
 	endpoint1.Receive(func(packet base.Packet, now time.Time) []base.Event {
@@ -41,6 +47,11 @@ func echo() {
 		println("endpoint 2 receive:", string(packet.(base.RawPacket)), "at", now.String())
 		return base.Aggregate(endpoint2.Send(packet, now))
 	})
-	network.Run([]base.Event{endpoint1.Send(base.RawPacket("hello world"), now)}, tick.NewStepClock(now, time.Second), 30*time.Second)
-	defer network.Wait()
+	err = network.Run([]base.Event{endpoint1.Send(base.RawPacket("hello world"), now)}, tick.NewStepClock(now, time.Second), 30*time.Second)
+	if err != nil {
+		panic(err)
+	}
+	if _, err = network.Wait(); err != nil {
+		panic(err)
+	}
 }
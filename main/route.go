@@ -23,7 +23,7 @@ func route() {
 		panic("no route to host")
 	}))
 	client := node.NewEndpointNode()
-	network, nodes := helper.
+	network, nodes, err := helper.
 		Chain().
 		Node(client).
 		Node(scatter).
@@ -35,6 +35,9 @@ func route() {
 		NodeWithName("route2", node.NewChannelNode(node.WithDelay(math.NewFixedDelay(time.Millisecond*300)))).
 		NodeWithName("server2", node.NewEndpointNode()).
 		Build()
+	if err != nil {
+		panic(err)
+	}
 	server1 := nodes["server1"].(*node.EndpointNode)
 	server2 := nodes["server2"].(*node.EndpointNode)
 	route1 := nodes["route1"]
@@ -49,8 +52,13 @@ func route() {
 	events := make([]base.Event, 0)
 	events = append(events, sender(base.RawPacket([]byte{}), "192.168.0.1", t.Add(time.Second*1))) // send to server1 after 1 second
 	events = append(events, sender(base.RawPacket([]byte{}), "192.168.0.2", t.Add(time.Second*2))) // send to server2 after 2 second
-	network.Run(events, tick.NewStepClock(t, time.Millisecond), 300*time.Second)
-	defer network.Wait()
+	err = network.Run(events, tick.NewStepClock(t, time.Millisecond), 300*time.Second)
+	if err != nil {
+		panic(err)
+	}
+	if _, err = network.Wait(); err != nil {
+		panic(err)
+	}
 }
 
 func react1(packet base.Packet, now time.Time) []base.Event {
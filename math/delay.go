@@ -5,6 +5,7 @@ import (
 	"github.com/bytedance/ns-x/v2/node"
 	"math"
 	"math/rand"
+	"strconv"
 	"time"
 )
 
@@ -12,40 +13,68 @@ import (
 
 // NewFixedDelay always delay given duration
 func NewFixedDelay(delay time.Duration) node.Delay {
+	return NewFixedDelayModel(delay).Delay
+}
+
+// NewFixedDelayModel same as NewFixedDelay, with the model description
+func NewFixedDelayModel(delay time.Duration) node.DescribedDelay {
 	if delay < 0 {
 		panic("invalid argument")
 	}
-	return func(base.Packet) time.Duration {
+	return node.DescribedDelay{Delay: func(base.Packet) time.Duration {
 		return delay
-	}
+	}, Model: node.Model{Name: "fixed", Parameters: delay.String(), Min: delay, Mean: delay}}
 }
 
 // NewNormalDelay delay with a normal distribution
 func NewNormalDelay(average, sigma time.Duration, random *rand.Rand) node.Delay {
+	return NewNormalDelayModel(average, sigma, random).Delay
+}
+
+// NewNormalDelayModel same as NewNormalDelay, with the model description
+func NewNormalDelayModel(average, sigma time.Duration, random *rand.Rand) node.DescribedDelay {
 	if sigma < 0 || random == nil {
 		panic("invalid argument")
 	}
-	return func(base.Packet) time.Duration {
-		return average + time.Duration(random.NormFloat64()*float64(sigma))
+	min := average
+	if sigma > 0 {
+		min = node.MinDuration
 	}
+	return node.DescribedDelay{Delay: func(base.Packet) time.Duration {
+		return average + time.Duration(random.NormFloat64()*float64(sigma))
+	}, Model: node.Model{Name: "normal", Parameters: average.String() + ", " + sigma.String(), Min: min, Mean: average}}
 }
 
 // NewUniformDelay delay with a uniform distribution in [0, 2*average)
 func NewUniformDelay(average time.Duration, random *rand.Rand) node.Delay {
+	return NewUniformDelayModel(average, random).Delay
+}
+
+// NewUniformDelayModel same as NewUniformDelay, with the model description
+func NewUniformDelayModel(average time.Duration, random *rand.Rand) node.DescribedDelay {
 	if average <= 0 || random == nil {
 		panic("invalid argument")
 	}
-	return func(base.Packet) time.Duration {
+	return node.DescribedDelay{Delay: func(base.Packet) time.Duration {
 		return time.Duration(random.Int63n(int64(2 * average)))
-	}
+	}, Model: node.Model{Name: "uniform", Parameters: average.String(), Min: 0, Mean: average}}
 }
 
 // NewParetoDelay delay with a pareto distribution, see https://en.wikipedia.org/wiki/Pareto_distribution
 func NewParetoDelay(minDelay time.Duration, alpha float64, random *rand.Rand) node.Delay {
+	return NewParetoDelayModel(minDelay, alpha, random).Delay
+}
+
+// NewParetoDelayModel same as NewParetoDelay, with the model description
+func NewParetoDelayModel(minDelay time.Duration, alpha float64, random *rand.Rand) node.DescribedDelay {
 	if minDelay <= 0 || alpha <= 0 || random == nil {
 		panic("invalid argument")
 	}
-	return func(base.Packet) time.Duration {
-		return time.Duration(float64(minDelay) * math.Pow(random.Float64(), -1/alpha))
+	mean := node.MaxDuration
+	if alpha > 1 {
+		mean = time.Duration(float64(minDelay) * alpha / (alpha - 1))
 	}
+	return node.DescribedDelay{Delay: func(base.Packet) time.Duration {
+		return time.Duration(float64(minDelay) * math.Pow(random.Float64(), -1/alpha))
+	}, Model: node.Model{Name: "pareto", Parameters: minDelay.String() + ", " + strconv.FormatFloat(alpha, 'g', -1, 64), Min: minDelay, Mean: mean}}
 }
@@ -4,27 +4,44 @@ import (
 	"github.com/bytedance/ns-x/v2/base"
 	"github.com/bytedance/ns-x/v2/node"
 	"math/rand"
+	"strconv"
+	"strings"
 )
 
 // some commonly used loss model
 
 // NewRandomLoss loss with the given possibility
 func NewRandomLoss(possibility float64, random *rand.Rand) node.Loss {
+	return NewRandomLossModel(possibility, random).Loss
+}
+
+// NewRandomLossModel same as NewRandomLoss, with the model description
+func NewRandomLossModel(possibility float64, random *rand.Rand) node.DescribedLoss {
 	if possibility < 0 || possibility > 1 || random == nil {
 		panic("invalid argument")
 	}
-	return func(base.Packet) bool {
+	return node.DescribedLoss{Loss: func(base.Packet) bool {
 		return random.Float64() < possibility
-	}
+	}, Model: node.Model{Name: "random", Parameters: formatFloats(possibility), Rate: possibility}}
 }
 
 // NewGilbertLoss loss with gilbert-elliott model, see https://en.wikipedia.org/wiki/Burst_error
 func NewGilbertLoss(g2b, b2g float64, lossG, lossB float64, random *rand.Rand) node.Loss {
+	return NewGilbertLossModel(g2b, b2g, lossG, lossB, random).Loss
+}
+
+// NewGilbertLossModel same as NewGilbertLoss, with the model description
+func NewGilbertLossModel(g2b, b2g float64, lossG, lossB float64, random *rand.Rand) node.DescribedLoss {
 	if g2b < 0 || g2b > 1 || b2g < 0 || b2g > 1 || lossG < 0 || lossG > 1 || lossB < 0 || lossB > 1 || random == nil {
 		panic("invalid argument")
 	}
 	state := false // true for good state, false for bad state
-	return func(base.Packet) bool {
+	// expected loss rate in the stationary distribution of states
+	rate := lossB
+	if g2b+b2g > 0 {
+		rate = (b2g*lossG + g2b*lossB) / (g2b + b2g)
+	}
+	return node.DescribedLoss{Loss: func(base.Packet) bool {
 		loss := false
 		if state {
 			if random.Float64() < lossG {
@@ -42,5 +59,14 @@ func NewGilbertLoss(g2b, b2g float64, lossG, lossB float64, random *rand.Rand) n
 			}
 		}
 		return loss
+	}, Model: node.Model{Name: "gilbert", Parameters: formatFloats(g2b, b2g, lossG, lossB), Rate: rate}}
+}
+
+// formatFloats format the given parameters of models
+func formatFloats(values ...float64) string {
+	result := make([]string, len(values))
+	for i, value := range values {
+		result[i] = strconv.FormatFloat(value, 'g', -1, 64)
 	}
+	return strings.Join(result, ", ")
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/bytedance/ns-x/v2/base"
 	"github.com/bytedance/ns-x/v2/node"
 	"math/rand"
+	"strconv"
 	"time"
 )
 
@@ -12,11 +13,16 @@ import (
 // NewNormalReorder for correlation possibility, reorder same to last packet, or reorder with the given possibility
 // reorder means the packet will be sent delta time in advance
 func NewNormalReorder(delta time.Duration, possibility, correlation float64, random *rand.Rand) node.Reorder {
+	return NewNormalReorderModel(delta, possibility, correlation, random).Reorder
+}
+
+// NewNormalReorderModel same as NewNormalReorder, with the model description
+func NewNormalReorderModel(delta time.Duration, possibility, correlation float64, random *rand.Rand) node.DescribedReorder {
 	if delta < 0 || possibility < 0 || possibility > 1 || correlation < 0 || correlation > 1 || random == nil {
 		panic("invalid argument")
 	}
 	last := false
-	return func(base.Packet) time.Duration {
+	return node.DescribedReorder{Reorder: func(base.Packet) time.Duration {
 		if random.Float64() >= correlation {
 			last = random.Float64() < possibility
 		}
@@ -24,17 +30,22 @@ func NewNormalReorder(delta time.Duration, possibility, correlation float64, ran
 			return -delta
 		}
 		return 0
-	}
+	}, Model: node.Model{Name: "normal", Parameters: delta.String() + ", " + formatFloats(possibility, correlation), Min: -delta, Mean: -time.Duration(float64(delta) * possibility)}}
 }
 
 // NewGapReorder for following gap packets after a reorder packet, no reorder; otherwise same to normal reorder
 func NewGapReorder(delta time.Duration, possibility, correlation float64, gap uint, random *rand.Rand) node.Reorder {
+	return NewGapReorderModel(delta, possibility, correlation, gap, random).Reorder
+}
+
+// NewGapReorderModel same as NewGapReorder, with the model description
+func NewGapReorderModel(delta time.Duration, possibility, correlation float64, gap uint, random *rand.Rand) node.DescribedReorder {
 	if delta < 0 || possibility < 0 || possibility > 1 || correlation < 0 || correlation > 1 || random == nil {
 		panic("invalid argument")
 	}
 	count := uint(0)
 	last := false
-	return func(base.Packet) time.Duration {
+	return node.DescribedReorder{Reorder: func(base.Packet) time.Duration {
 		count++
 		if count < gap {
 			last = false
@@ -48,5 +59,5 @@ func NewGapReorder(delta time.Duration, possibility, correlation float64, gap ui
 			return -delta
 		}
 		return 0
-	}
+	}, Model: node.Model{Name: "gap", Parameters: delta.String() + ", " + formatFloats(possibility, correlation) + ", " + strconv.Itoa(int(gap)), Min: -delta, Mean: -time.Duration(float64(delta) * possibility)}}
 }
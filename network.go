@@ -1,76 +1,211 @@
 package ns_x
 
 import (
+	"context"
+	"errors"
 	"github.com/bytedance/ns-x/v2/base"
 	"github.com/bytedance/ns-x/v2/tick"
-	"runtime"
+	"go.uber.org/atomic"
 	"sync"
 	"time"
 )
 
 // Network Indicates a simulated network, which contains some simulated nodes
 type Network struct {
-	nodes  []base.Node
-	buffer *base.EventBuffer
-	wg     *sync.WaitGroup
+	nodes    []base.Node
+	ids      map[base.Node]int // index of each node in nodes
+	names    map[string]base.Node
+	nodeName map[base.Node]string
+	buffer   *base.EventBuffer
+	wg       *sync.WaitGroup
+	running  *atomic.Bool
+	lock     *sync.Mutex // guard stopped, which is replaced by each simulation
+	stopped  *atomic.Bool
+	result   *RunResult
+	err      error
+	debugger *debugger
 }
 
 // NewNetwork creates a network with the given nodes, connections of nodes should be already established.
 func NewNetwork(nodes []base.Node) *Network {
+	ids := make(map[base.Node]int, len(nodes))
+	for i, node := range nodes {
+		ids[node] = i
+	}
 	return &Network{
-		nodes:  nodes,
-		buffer: base.NewEventBuffer(),
-		wg:     &sync.WaitGroup{},
+		nodes:    nodes,
+		ids:      ids,
+		names:    map[string]base.Node{},
+		nodeName: map[base.Node]string{},
+		buffer:   base.NewEventBuffer(),
+		wg:       &sync.WaitGroup{},
+		running:  atomic.NewBool(false),
+		lock:     &sync.Mutex{},
+		stopped:  atomic.NewBool(false),
+		debugger: newDebugger(),
 	}
 }
 
-// eventLoop Main polling loop of network
-func (n *Network) eventLoop(eventQueue *base.EventQueue, clock tick.Clock, lifetime time.Duration) {
-	runtime.LockOSThread()
-	defer runtime.UnlockOSThread()
-	defer n.wg.Done()
-	now := clock()
-	deadline := now.Add(lifetime)
-	println("network main loop start at", now.String())
-	for !now.After(deadline) && !eventQueue.IsEmpty() {
-		p := eventQueue.Peek()
-		t := p.Time()
-		if t.After(now) {
-			now = clock()
-			continue
+// flatten return the given nodes followed by internal nodes of base.Composite nodes recursively, each node once
+func flatten(nodes []base.Node) []base.Node {
+	result := append([]base.Node(nil), nodes...)
+	visited := make(map[base.Node]bool, len(nodes))
+	for _, node := range nodes {
+		visited[node] = true
+	}
+	for i := 0; i < len(result); i++ {
+		if composite, ok := result[i].(base.Composite); ok {
+			for _, member := range composite.Members() {
+				if !visited[member] {
+					visited[member] = true
+					result = append(result, member)
+				}
+			}
 		}
-		events := p.Action()(t)
-		eventQueue.Dequeue()
-		for _, event := range events {
-			eventQueue.Enqueue(event)
+	}
+	return result
+}
+
+// Check whether all nodes of the network can work correctly, return all errors found
+func (n *Network) Check() error {
+	var errs Errors
+	for _, node := range n.nodes {
+		if err := node.Check(); err != nil {
+			errs = append(errs, err)
 		}
 	}
-	println("network main loop end at", now.String())
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
 }
 
 // Run with the given config, users should Wait before another simulation or exit
 // some Config can be used on the simulation, default valued will be used if not specified
 // simulation will finish once no events remain or reach lifetime
-func (n *Network) Run(events []base.Event, clock tick.Clock, lifetime time.Duration, configs ...Config) {
-	n.wg.Add(1)
-	for _, node := range n.nodes {
-		node.Check()
+// return error if the network cannot work correctly or another simulation is running, in which case nothing is started
+func (n *Network) Run(events []base.Event, clock tick.Clock, lifetime time.Duration, configs ...Config) error {
+	return n.RunContext(context.Background(), events, clock, lifetime, configs...)
+}
+
+// RunContext same to Run, but the simulation will also be stopped once the given context is done
+func (n *Network) RunContext(ctx context.Context, events []base.Event, clock tick.Clock, lifetime time.Duration, configs ...Config) error {
+	if err := n.Check(); err != nil {
+		return err
+	}
+	if !n.running.CAS(false, true) {
+		return errors.New("another simulation is running")
 	}
+	n.wg.Add(1)
 	config := &config{
 		bucketSize: DefaultBucketSize,
 		maxBuckets: DefaultMaxBuckets,
 	}
 	config.apply(configs...)
-	eventQueue := base.NewEventQueue(config.bucketSize, config.maxBuckets)
+	var eventQueue base.EventScheduler
+	if config.scheduler != nil {
+		eventQueue = config.scheduler()
+	} else if config.adaptive {
+		eventQueue = base.NewAdaptiveEventQueue()
+	} else {
+		eventQueue = base.NewEventQueue(config.bucketSize, config.maxBuckets)
+	}
+	stopped := atomic.NewBool(false)
+	n.lock.Lock()
+	n.stopped = stopped
+	n.lock.Unlock()
+	n.result, n.err = nil, nil
+	s := &simulation{
+		network: n,
+		queue:   eventQueue,
+		clock:   clock,
+		config:  config,
+		stopped: stopped,
+		done:    make(chan struct{}),
+	}
+	s.now = clock()
+	s.start = s.now
+	s.deadline = s.now.Add(lifetime)
 	for _, event := range events {
-		eventQueue.Enqueue(event)
+		s.inject(event)
 	}
-	go n.eventLoop(eventQueue, clock, lifetime)
+	err := s.trace()
+	if err == nil {
+		err = s.exportCausality()
+	}
+	if err != nil {
+		n.wg.Done()
+		n.running.Store(false)
+		return err
+	}
+	if ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				stopped.Store(true)
+				n.debugger.wake()
+				n.buffer.Notify()
+			case <-s.done:
+			}
+		}()
+	}
+	n.debugger.start()
+	go s.eventLoop()
+	return nil
+}
+
+// Stop the running simulation, events not handled yet can be found in the RunResult, returned immediately
+func (n *Network) Stop() {
+	n.lock.Lock()
+	stopped := n.stopped
+	n.lock.Unlock()
+	stopped.Store(true)
+	n.debugger.wake()
+	n.buffer.Notify()
+}
+
+// Inject events into the simulation, thread-safe
+// events injected are merged into the running simulation as soon as possible, or the next simulation if not running
+// events before current time in simulated clock will be handled immediately
+func (n *Network) Inject(events ...base.Event) {
+	n.buffer.Insert(events...)
+}
+
+// Schedule inject an event with the given action at the given time point, thread-safe, see Inject
+// return the handle of the event, which can be used to cancel or reschedule the event
+func (n *Network) Schedule(t time.Time, action base.Action) *base.Handle {
+	handle := base.NewHandle(action, t, n.Inject)
+	n.Inject(handle.Event())
+	return handle
+}
+
+// ScheduleWithPriority same to Schedule, but the event is handled in the order of the given priority at the same time point
+func (n *Network) ScheduleWithPriority(t time.Time, priority base.Priority, action base.Action) *base.Handle {
+	handle := base.NewHandleWithPriority(action, t, priority, n.Inject)
+	n.Inject(handle.Event())
+	return handle
+}
+
+// ScheduleRepeat same to Schedule, but the event is repeated after the delay returned, until cancelled or the delay is negative
+func (n *Network) ScheduleRepeat(t time.Time, action base.RepeatAction) *base.Handle {
+	handle := base.NewRepeatHandle(action, t, n.Inject)
+	n.Inject(handle.Event())
+	return handle
+}
+
+// ScheduleRepeatWithPriority same to ScheduleRepeat, but events are handled in the order of the given priority at the same time point
+func (n *Network) ScheduleRepeatWithPriority(t time.Time, priority base.Priority, action base.RepeatAction) *base.Handle {
+	handle := base.NewRepeatHandleWithPriority(action, t, priority, n.Inject)
+	n.Inject(handle.Event())
+	return handle
 }
 
-// Wait until simulation finish
-func (n *Network) Wait() {
+// Wait until simulation finish, return the result of the simulation
+// if a panic raised when handling events, the simulation is finished and an EventError is returned
+// return nil result if no simulation ever finished
+func (n *Network) Wait() (*RunResult, error) {
 	n.wg.Wait()
+	return n.result, n.err
 }
 
 // Nodes return all nodes managed by the network
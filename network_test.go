@@ -0,0 +1,324 @@
+package ns_x
+
+import (
+	"context"
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/math"
+	"github.com/bytedance/ns-x/v2/node"
+	"github.com/bytedance/ns-x/v2/tick"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestVirtualTime(t *testing.T) {
+	delay := 150 * time.Millisecond
+	network, nodes, err := NewBuilder().
+		Chain().
+		NodeWithName("sender", node.NewEndpointNode()).
+		Node(node.NewChannelNode(node.WithDelay(math.NewFixedDelay(delay)))).
+		NodeWithName("receiver", node.NewEndpointNode()).
+		Build()
+	assert.NoError(t, err)
+	sender := nodes["sender"].(*node.EndpointNode)
+	receiver := nodes["receiver"].(*node.EndpointNode)
+	now := time.Now()
+	var received []time.Time
+	receiver.Receive(func(packet base.Packet, now time.Time) []base.Event {
+		received = append(received, now)
+		return nil
+	})
+	period := time.Second
+	count := 0
+	events := []base.Event{base.NewPeriodicEvent(func(t time.Time) []base.Event {
+		count++
+		return base.Aggregate(sender.Send(base.RawPacket{}, t))
+	}, period, now)}
+	start := time.Now()
+	assert.NoError(t, network.Run(events, tick.NewStepClock(now, time.Nanosecond), 300*time.Second, WithVirtualTime()))
+	_, err = network.Wait()
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.Equal(t, 301, count)
+	assert.Equal(t, 300, len(received))
+	for i, r := range received {
+		assert.Equal(t, now.Add(time.Duration(i)*period+delay), r)
+	}
+}
+
+func TestStop(t *testing.T) {
+	network := NewNetwork(nil)
+	now := time.Now()
+	events := []base.Event{base.NewPeriodicEvent(func(t time.Time) []base.Event {
+		return nil
+	}, time.Millisecond, now)}
+	assert.NoError(t, network.Run(events, tick.NewRealClock(), time.Hour))
+	time.Sleep(10 * time.Millisecond)
+	network.Stop()
+	result, err := network.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, Cancelled, result.Reason)
+	assert.Equal(t, 1, len(result.Remaining))
+	assert.True(t, result.End.Before(now.Add(time.Hour)))
+}
+
+func TestStopRace(t *testing.T) {
+	network := NewNetwork(nil)
+	now := time.Now()
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				network.Stop()
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		events := []base.Event{base.NewFixedEvent(func(t time.Time) []base.Event { return nil }, now)}
+		assert.NoError(t, network.Run(events, tick.NewStepClock(now, time.Millisecond), time.Second, WithVirtualTime()))
+		_, err := network.Wait()
+		assert.NoError(t, err)
+	}
+	close(done)
+}
+
+func TestRunContext(t *testing.T) {
+	network := NewNetwork(nil)
+	now := time.Now()
+	events := []base.Event{base.NewPeriodicEvent(func(t time.Time) []base.Event {
+		return nil
+	}, time.Millisecond, now)}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, network.RunContext(ctx, events, tick.NewRealClock(), time.Hour))
+	result, err := network.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, Cancelled, result.Reason)
+}
+
+func TestStopReason(t *testing.T) {
+	network := NewNetwork(nil)
+	now := time.Now()
+	events := []base.Event{
+		base.NewFixedEvent(func(t time.Time) []base.Event { return nil }, now),
+		base.NewFixedEvent(func(t time.Time) []base.Event { return nil }, now.Add(time.Second)),
+	}
+	assert.NoError(t, network.Run(events, tick.NewStepClock(now, time.Millisecond), 2*time.Second, WithVirtualTime()))
+	result, err := network.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, Drained, result.Reason)
+	assert.Empty(t, result.Remaining)
+	late := now.Add(time.Minute)
+	events = []base.Event{
+		base.NewFixedEvent(func(t time.Time) []base.Event { return nil }, now),
+		base.NewFixedEvent(func(t time.Time) []base.Event { return nil }, late),
+	}
+	assert.NoError(t, network.Run(events, tick.NewStepClock(now, time.Millisecond), 2*time.Second, WithVirtualTime()))
+	result, err = network.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, Expired, result.Reason)
+	assert.Equal(t, 1, len(result.Remaining))
+	assert.Equal(t, late, result.Remaining[0].Time())
+}
+
+func TestInject(t *testing.T) {
+	network, nodes, err := NewBuilder().
+		Chain().
+		NodeWithName("sender", node.NewEndpointNode()).
+		Node(node.NewChannelNode(node.WithDelay(math.NewFixedDelay(time.Millisecond)))).
+		NodeWithName("receiver", node.NewEndpointNode()).
+		Build()
+	assert.NoError(t, err)
+	sender := nodes["sender"].(*node.EndpointNode)
+	receiver := nodes["receiver"].(*node.EndpointNode)
+	received := make(chan time.Time, 1)
+	receiver.Receive(func(packet base.Packet, now time.Time) []base.Event {
+		received <- now
+		return nil
+	})
+	assert.NoError(t, network.Run(nil, tick.NewRealClock(), time.Hour, WithKeepAlive()))
+	for i := 0; i < 10; i++ {
+		now := time.Now()
+		network.Inject(sender.Send(base.RawPacket{}, now))
+		assert.Equal(t, now.Add(time.Millisecond), <-received)
+	}
+	scheduled := make(chan time.Time, 1)
+	at := time.Now().Add(time.Millisecond)
+	network.Schedule(at, func(now time.Time) []base.Event {
+		scheduled <- now
+		return nil
+	})
+	assert.Equal(t, at, <-scheduled)
+	network.Stop()
+	result, err := network.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, Cancelled, result.Reason)
+}
+
+func TestInjectVirtualTime(t *testing.T) {
+	network := NewNetwork(nil)
+	now := time.Now()
+	assert.NoError(t, network.Run(nil, tick.NewStepClock(now, time.Millisecond), time.Hour, WithVirtualTime(), WithKeepAlive()))
+	// the idle loop waits for injected events
+	time.Sleep(10 * time.Millisecond)
+	handled := make(chan time.Time, 1)
+	network.Schedule(now.Add(time.Minute), func(t time.Time) []base.Event {
+		handled <- t
+		return nil
+	})
+	assert.Equal(t, now.Add(time.Minute), <-handled)
+	time.Sleep(10 * time.Millisecond)
+	network.Stop()
+	result, err := network.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, Cancelled, result.Reason)
+	assert.Equal(t, now.Add(time.Minute), result.End)
+}
+
+func TestPanic(t *testing.T) {
+	network, nodes, err := NewBuilder().
+		Chain().
+		NodeWithName("sender", node.NewEndpointNode()).
+		Node(node.NewChannelNode(node.WithDelay(math.NewFixedDelay(time.Second)))).
+		NodeWithName("receiver", node.NewEndpointNode()).
+		Build()
+	assert.NoError(t, err)
+	sender := nodes["sender"].(*node.EndpointNode)
+	receiver := nodes["receiver"].(*node.EndpointNode)
+	receiver.Receive(func(packet base.Packet, now time.Time) []base.Event {
+		panic("broken receiver")
+	})
+	now := time.Now()
+	assert.NoError(t, network.Run([]base.Event{sender.Send(base.RawPacket{}, now)}, tick.NewStepClock(now, time.Millisecond), time.Minute, WithVirtualTime()))
+	result, err := network.Wait()
+	assert.Equal(t, Failed, result.Reason)
+	eventError, ok := err.(*EventError)
+	assert.True(t, ok)
+	assert.Equal(t, receiver, eventError.Node)
+	assert.Equal(t, now.Add(time.Second), eventError.Time)
+	assert.Equal(t, "broken receiver", eventError.Value)
+	assert.NotEmpty(t, eventError.Stack)
+}
+
+func TestCheck(t *testing.T) {
+	_, _, err := NewBuilder().
+		Chain().
+		NodeOfName("missing").
+		Build()
+	assert.Error(t, err)
+	network := NewNetwork([]base.Node{node.NewChannelNode()})
+	assert.Error(t, network.Run(nil, tick.NewRealClock(), time.Second))
+	result, err := network.Wait()
+	assert.Nil(t, result)
+	assert.NoError(t, err)
+}
+
+type trace struct {
+	packet int
+	target int
+	time   time.Time
+}
+
+func TestDeterministic(t *testing.T) {
+	now := time.Now()
+	run := func() []trace {
+		random := rand.New(rand.NewSource(0))
+		var traces []trace
+		ids := map[base.Node]int{}
+		callback := func(packet base.Packet, source, target base.Node, now time.Time) {
+			traces = append(traces, trace{packet: int(packet.(base.RawPacket)[0]), target: ids[target], time: now})
+		}
+		builder := NewBuilder().
+			Chain().
+			NodeWithName("sender", node.NewEndpointNode()).
+			NodeWithName("broadcast", node.NewBroadcastNode(node.WithTransferCallback(callback))).
+			Chain().
+			NodeWithName("gather", node.NewGatherNode(node.WithTransferCallback(callback))).
+			NodeWithName("receiver", node.NewEndpointNode())
+		for i := 0; i < 8; i++ {
+			builder.Chain().
+				NodeOfName("broadcast").
+				Node(node.NewChannelNode(node.WithTransferCallback(callback), node.WithLoss(math.NewRandomLoss(0.2, random)))).
+				NodeOfName("gather")
+		}
+		network, nodes, err := builder.Build()
+		assert.NoError(t, err)
+		for index, n := range network.Nodes() {
+			ids[n] = index
+		}
+		sender := nodes["sender"].(*node.EndpointNode)
+		events := make([]base.Event, 0)
+		for i := 0; i < 16; i++ {
+			events = append(events, sender.Send(base.RawPacket{byte(i)}, now))
+		}
+		assert.NoError(t, network.Run(events, tick.NewStepClock(now, time.Millisecond), time.Minute, WithVirtualTime()))
+		_, err = network.Wait()
+		assert.NoError(t, err)
+		return traces
+	}
+	expected := run()
+	assert.NotEmpty(t, expected)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, expected, run())
+	}
+}
+
+func TestScheduleHandle(t *testing.T) {
+	network := NewNetwork(nil)
+	now := time.Now()
+	var fired []time.Time
+	record := func(t time.Time) []base.Event {
+		fired = append(fired, t)
+		return nil
+	}
+	cancelled := network.Schedule(now.Add(time.Second), record)
+	rescheduled := network.Schedule(now.Add(2*time.Second), record)
+	network.Schedule(now.Add(time.Second), func(t time.Time) []base.Event {
+		rescheduled.Reschedule(t.Add(3 * time.Second))
+		return nil
+	})
+	count := 0
+	network.ScheduleRepeat(now, func(t time.Time) ([]base.Event, time.Duration) {
+		count++
+		if count == 1 {
+			cancelled.Cancel()
+		}
+		return nil, 500 * time.Millisecond
+	}).Reschedule(now.Add(500 * time.Millisecond))
+	assert.NoError(t, network.Run(nil, tick.NewStepClock(now, time.Millisecond), 10*time.Second, WithVirtualTime()))
+	_, err := network.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{now.Add(4 * time.Second)}, fired)
+	assert.Equal(t, 20, count)
+}
+
+func TestSchedulePriority(t *testing.T) {
+	network := NewNetwork(nil)
+	now := time.Now()
+	var order []string
+	record := func(name string) base.Action {
+		return func(t time.Time) []base.Event {
+			order = append(order, name)
+			return nil
+		}
+	}
+	network.Schedule(now.Add(time.Second), record("packet"))
+	network.ScheduleWithPriority(now.Add(time.Second), base.PriorityControl, record("control"))
+	count := 0
+	network.ScheduleRepeatWithPriority(now.Add(time.Second), base.PriorityControl, func(t time.Time) ([]base.Event, time.Duration) {
+		count++
+		order = append(order, "repeat")
+		if count == 2 {
+			return nil, -1
+		}
+		return nil, 0
+	})
+	assert.NoError(t, network.Run(nil, tick.NewStepClock(now, time.Millisecond), time.Minute, WithVirtualTime()))
+	_, err := network.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"control", "repeat", "repeat", "packet"}, order)
+}
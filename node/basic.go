@@ -2,6 +2,7 @@ package node
 
 import (
 	"github.com/bytedance/ns-x/v2/base"
+	"runtime/debug"
 	"time"
 )
 
@@ -11,11 +12,54 @@ type BasicNode struct {
 	callback base.TransferCallback
 }
 
-func (n *BasicNode) actualTransfer(packet base.Packet, source, target base.Node, now time.Time) []base.Event {
+// transfer append the event transferring the packet to the target at the given time to the buffer
+func (n *BasicNode) transfer(buffer []base.Event, packet base.Packet, source, target base.Node, t time.Time) []base.Event {
+	return append(buffer, newTransferEvent(n, packet, source, target, t))
+}
+
+// actualTransfer transfer the packet to the target, with events appended to the buffer if it's not nil
+func (n *BasicNode) actualTransfer(packet base.Packet, source, target base.Node, now time.Time, buffer []base.Event) []base.Event {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(*base.TransferError); ok {
+				panic(r)
+			}
+			panic(&base.TransferError{Node: target, Packet: packet, Value: r, Stack: debug.Stack()})
+		}
+	}()
 	if n.callback != nil {
 		n.callback(packet, source, target, now)
 	}
-	return target.Transfer(packet, now)
+	if events, ok := emit(target, packet, now, buffer); ok {
+		return events
+	}
+	events := target.Transfer(packet, now)
+	if buffer == nil {
+		return events
+	}
+	// copy into the buffer, so that slices returned by other nodes are never reused
+	return append(buffer, events...)
+}
+
+// emit transfer the packet to the target with events appended to the buffer, return false if not a built-in node
+// Transfer of built-in nodes return fresh slices, while transfer events pass buffers of their own, reused once recycled
+// types embedding built-in nodes are not matched, since they may override Transfer
+func emit(target base.Node, packet base.Packet, now time.Time, buffer []base.Event) ([]base.Event, bool) {
+	switch n := target.(type) {
+	case *ChannelNode:
+		return n.emit(packet, now, buffer), true
+	case *RestrictNode:
+		return n.emit(packet, now, buffer), true
+	case *GatherNode:
+		return n.emit(packet, now, buffer), true
+	case *ScatterNode:
+		return n.emit(packet, now, buffer), true
+	case *BroadcastNode:
+		return n.emit(packet, now, buffer), true
+	case *SubnetNode:
+		return n.emit(packet, now, buffer), true
+	}
+	return nil, false
 }
 
 func (n *BasicNode) GetTransferCallback() base.TransferCallback {
@@ -34,7 +78,8 @@ func (n *BasicNode) SetNext(nodes ...base.Node) {
 	n.next = nodes
 }
 
-func (n *BasicNode) Check() {
+func (n *BasicNode) Check() error {
+	return nil
 }
 
 // WithTransferCallback create an option to set/overwrite the given transfer callback to nodes applied
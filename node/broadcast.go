@@ -21,11 +21,12 @@ func NewBroadcastNode(options ...Option) *BroadcastNode {
 }
 
 func (n *BroadcastNode) Transfer(packet base.Packet, now time.Time) []base.Event {
-	events := make([]base.Event, len(n.GetNext()))
-	for index, node := range n.GetNext() {
-		events[index] = base.NewFixedEvent(func(t time.Time) []base.Event {
-			return n.actualTransfer(packet, n, node, t)
-		}, now)
+	return n.emit(packet, now, nil)
+}
+
+func (n *BroadcastNode) emit(packet base.Packet, now time.Time, buffer []base.Event) []base.Event {
+	for _, node := range n.GetNext() {
+		buffer = n.transfer(buffer, packet, n, node, now)
 	}
-	return events
+	return buffer
 }
@@ -1,6 +1,7 @@
 package node
 
 import (
+	"errors"
 	"github.com/bytedance/ns-x/v2/base"
 	"time"
 )
@@ -37,7 +38,13 @@ func combine(handlers ...handler) handler {
 // ChannelNode is a simulated network channel with loss, delay and reorder features
 type ChannelNode struct {
 	*BasicNode
-	handler handler
+	handler      handler
+	delayModels  []Model // models of delays and reorders
+	lossModels   []Model
+	unknownDelay bool // whether any delay or reorder has no model description
+	unknownLoss  bool // whether any loss has no model description
+	parameters   []string
+	hook         base.DecisionHook
 }
 
 // NewChannelNode creates a new ChannelNode with the given options
@@ -50,69 +57,164 @@ func NewChannelNode(options ...Option) *ChannelNode {
 }
 
 func (n *ChannelNode) Transfer(packet base.Packet, now time.Time) []base.Event {
-	delay := time.Duration(0)
-	loss := false
-	if n.handler != nil {
-		delay, loss = n.handler(packet)
-	}
+	return n.emit(packet, now, nil)
+}
+
+func (n *ChannelNode) emit(packet base.Packet, now time.Time, buffer []base.Event) []base.Event {
+	delay, loss := n.decide(packet)
 	if loss {
-		return nil
+		return buffer
 	}
 	if delay < 0 {
 		delay = 0
 	}
-	return base.Aggregate(
-		base.NewDelayedEvent(func(t time.Time) []base.Event {
-			return n.actualTransfer(packet, n, n.GetNext()[0], t)
-		}, delay, now),
-	)
+	return n.transfer(buffer, packet, n, n.GetNext()[0], now.Add(delay))
+}
+
+// decide the delay and loss of the packet, through the decision hook if set
+func (n *ChannelNode) decide(packet base.Packet) (time.Duration, bool) {
+	if n.hook == nil {
+		if n.handler == nil {
+			return 0, false
+		}
+		return n.handler(packet)
+	}
+	decide := func() base.Decision {
+		if n.handler == nil {
+			return base.Decision{}
+		}
+		delay, loss := n.handler(packet)
+		return base.Decision{Lost: loss, Delay: delay}
+	}
+	decision := n.hook(n, packet, decide)
+	return decision.Delay, decision.Lost
+}
+
+func (n *ChannelNode) SetDecisionHook(hook base.DecisionHook) {
+	n.hook = hook
+}
+
+// MinDelay return the lower bound of delay of any packet through the node, false if any delay or reorder has no model description
+func (n *ChannelNode) MinDelay() (time.Duration, bool) {
+	if n.unknownDelay {
+		return 0, false
+	}
+	result := time.Duration(0)
+	for _, model := range n.delayModels {
+		result = addDuration(result, model.Min)
+	}
+	if result < 0 {
+		result = 0
+	}
+	return result, true
+}
+
+// MeanDelay return the expected delay of packets through the node, MaxDuration if unbounded,
+// false if any delay or reorder has no model description
+func (n *ChannelNode) MeanDelay() (time.Duration, bool) {
+	if n.unknownDelay {
+		return 0, false
+	}
+	result := time.Duration(0)
+	for _, model := range n.delayModels {
+		result = addMean(result, model.Mean)
+	}
+	if result < 0 {
+		result = 0
+	}
+	return result, true
+}
+
+// LossRate return the expected loss rate of packets through the node, assuming losses independent,
+// false if any loss has no model description
+func (n *ChannelNode) LossRate() (float64, bool) {
+	if n.unknownLoss {
+		return 0, false
+	}
+	pass := 1.0
+	for _, model := range n.lossModels {
+		pass *= 1 - model.Rate
+	}
+	return 1 - pass, true
 }
 
-func (n *ChannelNode) Check() {
+// Describe return models of losses, delays and reorders in the order applied
+func (n *ChannelNode) Describe() []string {
+	return n.parameters
+}
+
+func (n *ChannelNode) Check() error {
 	if len(n.GetNext()) != 1 {
-		panic("channel node can only has single connection")
+		return errors.New("channel node can only has single connection")
 	}
-	n.BasicNode.Check()
+	return n.BasicNode.Check()
 }
 
-// WithLoss create an Option to add a Loss on the ChannelNode applied
+// WithLoss create an Option to add a custom Loss on the ChannelNode applied
 // node applied must be a ChannelNode
 func WithLoss(loss Loss) Option {
+	return WithDescribedLoss(DescribedLoss{Loss: loss})
+}
+
+// WithDelay create an Option to add a custom Delay on the ChannelNode applied
+// node applied must be a ChannelNode
+func WithDelay(delay Delay) Option {
+	return WithDescribedDelay(DescribedDelay{Delay: delay})
+}
+
+// WithReorder create an Option to add a custom Reorder on the ChannelNode applied
+// node applied must be a ChannelNode
+func WithReorder(reorder Reorder) Option {
+	return WithDescribedReorder(DescribedReorder{Reorder: reorder})
+}
+
+// WithDescribedLoss create an Option to add a Loss with its model description on the ChannelNode applied
+// node applied must be a ChannelNode
+func WithDescribedLoss(loss DescribedLoss) Option {
 	return func(node base.Node) {
 		n, ok := node.(*ChannelNode)
 		if !ok {
 			panic("cannot set loss")
 		}
 		n.handler = combine(n.handler, func(packet base.Packet) (time.Duration, bool) {
-			return 0, loss(packet)
+			return 0, loss.Loss(packet)
 		})
+		n.lossModels = append(n.lossModels, loss.Model)
+		n.unknownLoss = n.unknownLoss || !loss.Model.known()
+		n.parameters = append(n.parameters, parameter("loss", loss.Model))
 	}
 }
 
-// WithDelay create an Option to add a Delay on the ChannelNode applied
+// WithDescribedDelay create an Option to add a Delay with its model description on the ChannelNode applied
 // node applied must be a ChannelNode
-func WithDelay(delay Delay) Option {
+func WithDescribedDelay(delay DescribedDelay) Option {
 	return func(node base.Node) {
 		n, ok := node.(*ChannelNode)
 		if !ok {
 			panic("cannot set delay")
 		}
 		n.handler = combine(n.handler, func(packet base.Packet) (time.Duration, bool) {
-			return delay(packet), false
+			return delay.Delay(packet), false
 		})
+		n.delayModels = append(n.delayModels, delay.Model)
+		n.unknownDelay = n.unknownDelay || !delay.Model.known()
+		n.parameters = append(n.parameters, parameter("delay", delay.Model))
 	}
 }
 
-// WithReorder create an Option to add a Reorder on the ChannelNode applied
+// WithDescribedReorder create an Option to add a Reorder with its model description on the ChannelNode applied
 // node applied must be a ChannelNode
-func WithReorder(reorder Reorder) Option {
+func WithDescribedReorder(reorder DescribedReorder) Option {
 	return func(node base.Node) {
 		n, ok := node.(*ChannelNode)
 		if !ok {
 			panic("cannot set reorder")
 		}
 		n.handler = combine(n.handler, func(packet base.Packet) (time.Duration, bool) {
-			return reorder(packet), false
+			return reorder.Reorder(packet), false
 		})
+		n.delayModels = append(n.delayModels, reorder.Model)
+		n.unknownDelay = n.unknownDelay || !reorder.Model.known()
+		n.parameters = append(n.parameters, parameter("reorder", reorder.Model))
 	}
 }
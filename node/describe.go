@@ -0,0 +1,28 @@
+package node
+
+import (
+	"strconv"
+)
+
+// Describer is a node describing its key parameters, such as models of a ChannelNode, used to display the network
+type Describer interface {
+	// Describe return key parameters of the node, one per line
+	Describe() []string
+}
+
+// parameter format the model of the given kind, "custom" if the model is unknown
+func parameter(kind string, model Model) string {
+	if !model.known() {
+		return kind + ": custom"
+	}
+	return kind + ": " + model.String()
+}
+
+// formatLimit format the limit and the queue limit in the given unit, -1 means unlimited
+func formatLimit(limit float64, queue int64, unit string) string {
+	result := strconv.FormatFloat(limit, 'g', -1, 64)
+	if queue >= 0 {
+		result += ", queue " + strconv.FormatInt(queue, 10) + " " + unit
+	}
+	return result
+}
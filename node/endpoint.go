@@ -1,6 +1,7 @@
 package node
 
 import (
+	"errors"
 	"github.com/bytedance/ns-x/v2/base"
 	"time"
 )
@@ -39,10 +40,10 @@ func (n *EndpointNode) Send(packet base.Packet, t time.Time) base.Event {
 
 // SendSupplied same to Send, but packet to be sent is not supplied until the event occur
 func (n *EndpointNode) SendSupplied(supplier PacketSupplier, t time.Time) base.Event {
-	return base.NewFixedEvent(func(t time.Time) []base.Event {
+	return base.NewNodeEvent(n, func(t time.Time) []base.Event {
 		packet := supplier()
 		if packet != nil {
-			return n.actualTransfer(packet, n, n.GetNext()[0], t)
+			return n.actualTransfer(packet, n, n.GetNext()[0], t, nil)
 		} else {
 			return nil
 		}
@@ -55,11 +56,11 @@ func (n *EndpointNode) Receive(callback React) {
 	n.callback = callback
 }
 
-func (n *EndpointNode) Check() {
+func (n *EndpointNode) Check() error {
 	if len(n.GetNext()) > 1 {
-		panic("endpoint node can has at most single connection")
+		return errors.New("endpoint node can has at most single connection")
 	}
-	n.BasicNode.Check()
+	return n.BasicNode.Check()
 }
 
 type PacketSupplier func() base.Packet
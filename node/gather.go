@@ -1,6 +1,7 @@
 package node
 
 import (
+	"errors"
 	"github.com/bytedance/ns-x/v2/base"
 	"time"
 )
@@ -18,12 +19,16 @@ func NewGatherNode(options ...Option) *GatherNode {
 }
 
 func (n *GatherNode) Transfer(packet base.Packet, now time.Time) []base.Event {
+	return n.emit(packet, now, nil)
+}
+
+func (n *GatherNode) emit(packet base.Packet, now time.Time, buffer []base.Event) []base.Event {
+	return n.transfer(buffer, packet, n, n.GetNext()[0], now)
+}
+
+func (n *GatherNode) Check() error {
 	if len(n.GetNext()) != 1 {
-		panic("gather node can only has single connection")
+		return errors.New("gather node can only has single connection")
 	}
-	return base.Aggregate(
-		base.NewFixedEvent(func(t time.Time) []base.Event {
-			return n.actualTransfer(packet, n, n.GetNext()[0], t)
-		}, now),
-	)
+	return n.BasicNode.Check()
 }
@@ -0,0 +1,69 @@
+package node
+
+import (
+	"math"
+	"time"
+)
+
+// Model describes a loss, delay or reorder model, so that the network can be analysed without running it
+type Model struct {
+	// Name of the model, such as "fixed" or "normal"
+	Name string
+	// Parameters of the model, formatted for display
+	Parameters string
+	// Min is the lower bound of the delay, or the largest advance of reorder in negative, MinDuration if unbounded
+	Min time.Duration
+	// Mean is the expected delay, or the expected advance of reorder in negative, MaxDuration if unbounded
+	Mean time.Duration
+	// Rate is the expected loss rate, only for loss models
+	Rate float64
+}
+
+// MinDuration indicates an unbounded lower bound of delay
+const MinDuration = time.Duration(math.MinInt64)
+
+// MaxDuration indicates an unbounded expected delay, such as pareto delays with alpha not greater than 1
+const MaxDuration = time.Duration(math.MaxInt64)
+
+func (m Model) String() string {
+	return m.Name + "(" + m.Parameters + ")"
+}
+
+// DescribedDelay is a Delay with its model description, the delay is regarded as custom if the Model has no name
+type DescribedDelay struct {
+	Delay Delay
+	Model Model
+}
+
+// DescribedLoss is a Loss with its model description, the loss is regarded as custom if the Model has no name
+type DescribedLoss struct {
+	Loss  Loss
+	Model Model
+}
+
+// DescribedReorder is a Reorder with its model description, the reorder is regarded as custom if the Model has no name
+type DescribedReorder struct {
+	Reorder Reorder
+	Model   Model
+}
+
+// known whether the model is described
+func (m Model) known() bool {
+	return m.Name != ""
+}
+
+// addMean add expected durations, saturated to MaxDuration as unbounded once overflow
+func addMean(a, b time.Duration) time.Duration {
+	if a == MaxDuration || b == MaxDuration || (b > 0 && a > MaxDuration-b) {
+		return MaxDuration
+	}
+	return a + b
+}
+
+// addDuration add durations, while MinDuration is kept as unbounded
+func addDuration(a, b time.Duration) time.Duration {
+	if a == MinDuration || b == MinDuration {
+		return MinDuration
+	}
+	return a + b
+}
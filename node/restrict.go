@@ -1,6 +1,7 @@
 package node
 
 import (
+	"errors"
 	"github.com/bytedance/ns-x/v2/base"
 	"math"
 	"time"
@@ -34,11 +35,16 @@ func NewRestrictNode(options ...Option) *RestrictNode {
 }
 
 func (n *RestrictNode) Transfer(packet base.Packet, now time.Time) []base.Event {
+	return n.emit(packet, now, nil)
+}
+
+func (n *RestrictNode) emit(packet base.Packet, now time.Time, buffer []base.Event) []base.Event {
+	// queue overflows only if limits are lowered with packets queued, packets are dropped until the queue drains
 	if n.queuePacketsLimit >= 0 && n.queuePackets > n.queuePacketsLimit {
-		panic("queue is already overflow in packets")
+		return buffer
 	}
 	if n.queueBytesLimit >= 0 && n.queueBytes > n.queueBytesLimit {
-		panic("queue is already overflow in bytes")
+		return buffer
 	}
 	busy := false
 	t := now
@@ -48,20 +54,20 @@ func (n *RestrictNode) Transfer(packet base.Packet, now time.Time) []base.Event
 	}
 	if busy {
 		if n.queueBytesLimit >= 0 && n.queueBytes+int64(packet.Size()) > n.queueBytesLimit {
-			return nil
+			return buffer
 		}
 		if n.queuePacketsLimit >= 0 && n.queuePackets+1 > n.queuePacketsLimit {
-			return nil
+			return buffer
 		}
 	}
 	step := math.Max(1.0/n.ppsLimit, float64(packet.Size())/n.bpsLimit)
 	delta := time.Duration(step * float64(time.Second))
 	n.busyTime = t.Add(delta)
-	events := n.actualTransfer(packet, n, n.GetNext()[0], t)
+	events := n.actualTransfer(packet, n, n.GetNext()[0], t, buffer)
 	if busy {
 		n.queueBytes += int64(packet.Size())
 		n.queuePackets++
-		events = append(events, base.NewFixedEvent(func(t time.Time) []base.Event {
+		events = append(events, base.NewNodeEvent(n, func(t time.Time) []base.Event {
 			n.queueBytes -= int64(packet.Size())
 			n.queuePackets--
 			return nil
@@ -70,10 +76,33 @@ func (n *RestrictNode) Transfer(packet base.Packet, now time.Time) []base.Event
 	return events
 }
 
-func (n *RestrictNode) Check() {
+// Describe return limits in pps and bps with the queue limits
+func (n *RestrictNode) Describe() []string {
+	var result []string
+	if n.ppsLimit >= 0 {
+		result = append(result, "pps: "+formatLimit(n.ppsLimit, n.queuePacketsLimit, "packets"))
+	}
+	if n.bpsLimit >= 0 {
+		result = append(result, "bps: "+formatLimit(n.bpsLimit, n.queueBytesLimit, "bytes"))
+	}
+	return result
+}
+
+func (n *RestrictNode) Check() error {
 	if len(n.GetNext()) != 1 {
-		panic("restrict node can only has single connection")
+		return errors.New("restrict node can only has single connection")
 	}
+	return n.BasicNode.Check()
+}
+
+// PPSLimit retrieve the limit in packets per second, -1 if unlimited
+func (n *RestrictNode) PPSLimit() float64 {
+	return n.ppsLimit
+}
+
+// BPSLimit retrieve the limit in bytes per second, -1 if unlimited
+func (n *RestrictNode) BPSLimit() float64 {
+	return n.bpsLimit
 }
 
 // QueuePackets retrieve current count of packets in the queue
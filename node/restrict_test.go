@@ -44,3 +44,16 @@ func TestRestrictNodeBPSLimit(t *testing.T) {
 		assert.Equal(t, node.QueuePackets(), queueLimit)
 	}
 }
+
+func TestRestrictNodeOverflow(t *testing.T) {
+	node := NewRestrictNode(WithPPSLimit(1.0, 10))
+	node.SetNext(NewEndpointNode())
+	current := time.Now()
+	for i := 0; i < 6; i++ {
+		node.Transfer(base.RawPacket{}, current)
+	}
+	assert.Equal(t, int64(5), node.QueuePackets())
+	WithPPSLimit(1.0, 2)(node)
+	assert.Nil(t, node.Transfer(base.RawPacket{}, current))
+	assert.Equal(t, int64(5), node.QueuePackets())
+}
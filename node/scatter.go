@@ -12,6 +12,7 @@ type RouteSelector func(packet base.Packet, nodes []base.Node) base.Node
 type ScatterNode struct {
 	*BasicNode
 	selector RouteSelector
+	hook     base.DecisionHook
 }
 
 // NewScatterNode create a ScatterNode with given options
@@ -27,15 +28,40 @@ func NewScatterNode(options ...Option) *ScatterNode {
 }
 
 func (n *ScatterNode) Transfer(packet base.Packet, now time.Time) []base.Event {
-	path := n.selector(packet, n.GetNext())
+	return n.emit(packet, now, nil)
+}
+
+func (n *ScatterNode) emit(packet base.Packet, now time.Time, buffer []base.Event) []base.Event {
+	path := n.route(packet)
 	if path != nil {
-		return base.Aggregate(
-			base.NewFixedEvent(func(t time.Time) []base.Event {
-				return n.actualTransfer(packet, n, path, t)
-			}, now),
-		)
+		return n.transfer(buffer, packet, n, path, now)
+	}
+	return buffer
+}
+
+// route select the next node of the packet, through the decision hook if set
+func (n *ScatterNode) route(packet base.Packet) base.Node {
+	next := n.GetNext()
+	if n.hook == nil {
+		return n.selector(packet, next)
 	}
-	return nil
+	decision := n.hook(n, packet, func() base.Decision {
+		path := n.selector(packet, next)
+		for i, node := range next {
+			if node == path {
+				return base.Decision{Route: i}
+			}
+		}
+		return base.Decision{Route: -1}
+	})
+	if decision.Route < 0 || decision.Route >= len(next) {
+		return nil
+	}
+	return next[decision.Route]
+}
+
+func (n *ScatterNode) SetDecisionHook(hook base.DecisionHook) {
+	n.hook = hook
 }
 
 // WithRouteSelector create an option to set/overwrite route selector of nodes applied
@@ -0,0 +1,88 @@
+package node
+
+import (
+	"fmt"
+	"github.com/bytedance/ns-x/v2/base"
+	"strconv"
+	"time"
+)
+
+// SubnetNode is a node made of a sub-network of internal nodes, such as a model of home broadband or mobile carrier core
+// packets transferred to the node are transferred to the ingress node, and packets leaving the egress node are transferred
+// to next nodes of the SubnetNode, so that the sub-network can be used as a single node
+// internal nodes are not nodes of the network, but simulated as members of the node
+type SubnetNode struct {
+	*BasicNode
+	ingress, egress base.Node
+	members         []base.Node
+}
+
+// NewSubnetNode create a subnet node of the sub-network from the ingress node to the egress node
+// connections of internal nodes should be already established, except for the egress node whose next nodes are set by SetNext
+// members are the ingress node, the egress node and all nodes reachable from the ingress node without passing through the egress node
+func NewSubnetNode(ingress, egress base.Node, options ...Option) *SubnetNode {
+	n := &SubnetNode{BasicNode: &BasicNode{}, ingress: ingress, egress: egress}
+	visited := map[base.Node]bool{egress: true}
+	var visit func(node base.Node)
+	visit = func(node base.Node) {
+		if visited[node] {
+			return
+		}
+		visited[node] = true
+		n.members = append(n.members, node)
+		for _, next := range node.GetNext() {
+			visit(next)
+		}
+	}
+	visit(ingress)
+	if ingress != egress {
+		n.members = append(n.members, egress)
+	}
+	apply(n, options...)
+	return n
+}
+
+// Ingress return the node where packets enter the sub-network
+func (n *SubnetNode) Ingress() base.Node {
+	return n.ingress
+}
+
+// Egress return the node where packets leave the sub-network
+func (n *SubnetNode) Egress() base.Node {
+	return n.egress
+}
+
+func (n *SubnetNode) Members() []base.Node {
+	return n.members
+}
+
+func (n *SubnetNode) Transfer(packet base.Packet, now time.Time) []base.Event {
+	return n.emit(packet, now, nil)
+}
+
+func (n *SubnetNode) emit(packet base.Packet, now time.Time, buffer []base.Event) []base.Event {
+	return n.transfer(buffer, packet, n, n.ingress, now)
+}
+
+// GetNext return next nodes of the egress node
+func (n *SubnetNode) GetNext() []base.Node {
+	return n.egress.GetNext()
+}
+
+// SetNext set next nodes of the egress node
+func (n *SubnetNode) SetNext(nodes ...base.Node) {
+	n.egress.SetNext(nodes...)
+}
+
+func (n *SubnetNode) Check() error {
+	for _, member := range n.members {
+		if err := member.Check(); err != nil {
+			return fmt.Errorf("subnet node: internal node %T: %w", member, err)
+		}
+	}
+	return n.BasicNode.Check()
+}
+
+func (n *SubnetNode) Describe() []string {
+	return []string{"nodes: " + strconv.Itoa(len(n.members))}
+}
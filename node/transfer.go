@@ -0,0 +1,89 @@
+package node
+
+import (
+	"github.com/bytedance/ns-x/v2/base"
+	"sync"
+	"time"
+)
+
+// transferPool holds transfer events recycled, shared by all nodes
+var transferPool = sync.Pool{
+	New: func() interface{} {
+		return &transferEvent{buffer: make([]base.Event, 0, 1)}
+	},
+}
+
+// transferEvent is an event transferring the packet from the source to the target, which is the most frequent event
+// unlike closures, it is handled without allocations, and recycled into the pool once handled
+type transferEvent struct {
+	basic  *BasicNode
+	source base.Node
+	target base.Node
+	packet base.Packet
+	time   time.Time
+	cause  base.Cause
+	before []base.Action // hooked before, handled in reverse order
+	after  []base.Action // hooked after, handled in order
+	buffer []base.Event  // events returned, reused once recycled since the event loop takes them before recycling
+}
+
+// newTransferEvent get a transfer event from the pool
+func newTransferEvent(basic *BasicNode, packet base.Packet, source, target base.Node, t time.Time) *transferEvent {
+	e := transferPool.Get().(*transferEvent)
+	e.basic, e.source, e.target, e.packet, e.time = basic, source, target, packet, t
+	return e
+}
+
+func (e *transferEvent) Time() time.Time {
+	return e.time
+}
+
+func (e *transferEvent) Action() base.Action {
+	return e.Handle
+}
+
+func (e *transferEvent) Node() base.Node {
+	return e.target
+}
+
+// Origin is the node passing the packet, rather than the node bound to the parent event
+func (e *transferEvent) Origin() base.Node {
+	return e.source
+}
+
+func (e *transferEvent) Cause() *base.Cause {
+	return &e.cause
+}
+
+func (e *transferEvent) HookBefore(action base.Action) {
+	e.before = append(e.before, action)
+}
+
+func (e *transferEvent) HookAfter(action base.Action) {
+	e.after = append(e.after, action)
+}
+
+func (e *transferEvent) Handle(t time.Time) []base.Event {
+	if len(e.before) == 0 && len(e.after) == 0 {
+		e.buffer = e.basic.actualTransfer(e.packet, e.source, e.target, t, e.buffer[:0])
+		return e.buffer[:len(e.buffer):len(e.buffer)]
+	}
+	var events []base.Event
+	for i := len(e.before) - 1; i >= 0; i-- {
+		events = append(events, e.before[i](t)...)
+	}
+	events = e.basic.actualTransfer(e.packet, e.source, e.target, t, events)
+	for _, action := range e.after {
+		events = append(events, action(t)...)
+	}
+	return events
+}
+
+func (e *transferEvent) Recycle() {
+	buffer := e.buffer
+	for i := range buffer {
+		buffer[i] = nil
+	}
+	*e = transferEvent{buffer: buffer[:0]}
+	transferPool.Put(e)
+}
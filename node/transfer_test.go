@@ -0,0 +1,84 @@
+package node
+
+import (
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTransferEventHook(t *testing.T) {
+	now := time.Now()
+	var order []string
+	mark := func(name string) base.Action {
+		return func(t time.Time) []base.Event {
+			order = append(order, name)
+			return nil
+		}
+	}
+	target := NewEndpointNode()
+	target.Receive(func(packet base.Packet, now time.Time) []base.Event {
+		order = append(order, "transfer")
+		return nil
+	})
+	source := NewGatherNode()
+	source.SetNext(target)
+	events := source.Transfer(base.RawPacket{}, now)
+	assert.Equal(t, 1, len(events))
+	event := events[0]
+	assert.Same(t, target, event.Node())
+	event.HookBefore(mark("before1"))
+	event.HookBefore(mark("before2"))
+	event.HookAfter(mark("after1"))
+	event.HookAfter(mark("after2"))
+	base.Act(event, now)
+	assert.Equal(t, []string{"before2", "before1", "transfer", "after1", "after2"}, order)
+	base.Recycle(event)
+	order = nil
+	event = source.Transfer(base.RawPacket{}, now)[0]
+	event.Action()(now)
+	assert.Equal(t, []string{"transfer"}, order)
+}
+
+func TestTransferFreshEvents(t *testing.T) {
+	now := time.Now()
+	a, b := NewEndpointNode(), NewEndpointNode()
+	gather := NewGatherNode()
+	gather.SetNext(a)
+	first := gather.Transfer(base.RawPacket{}, now)
+	second := gather.Transfer(base.RawPacket{}, now.Add(time.Second))
+	assert.Equal(t, now, first[0].Time())
+	assert.Equal(t, now.Add(time.Second), second[0].Time())
+	broadcast := NewBroadcastNode()
+	broadcast.SetNext(a, b)
+	first = broadcast.Transfer(base.RawPacket{}, now)
+	second = broadcast.Transfer(base.RawPacket{}, now.Add(time.Second))
+	assert.Equal(t, 2, len(first))
+	for _, event := range first {
+		assert.Equal(t, now, event.Time())
+	}
+	for _, event := range second {
+		assert.Equal(t, now.Add(time.Second), event.Time())
+	}
+}
+
+// reusingNode return the same slice on each transfer
+type reusingNode struct {
+	*BasicNode
+	events []base.Event
+}
+
+func (n *reusingNode) Transfer(packet base.Packet, now time.Time) []base.Event {
+	n.events = append(n.events[:0], base.NewFixedEvent(func(time.Time) []base.Event { return nil }, now))
+	return n.events
+}
+
+func TestTransferBuffer(t *testing.T) {
+	now := time.Now()
+	gather := NewGatherNode()
+	gather.SetNext(&reusingNode{BasicNode: &BasicNode{}})
+	first := base.Act(gather.Transfer(base.RawPacket{}, now)[0], now)
+	second := base.Act(gather.Transfer(base.RawPacket{}, now.Add(time.Second))[0], now.Add(time.Second))
+	assert.Equal(t, now, first[0].Time())
+	assert.Equal(t, now.Add(time.Second), second[0].Time())
+}
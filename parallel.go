@@ -0,0 +1,440 @@
+package ns_x
+
+import (
+	"container/heap"
+	"fmt"
+	"github.com/bytedance/ns-x/v2/base"
+	"math"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// partition the nodes into at most the given count of partitions, so that packets between partitions are always delayed
+// return the partition index of each node, count of partitions actually used, and the lookahead of partitions,
+// which is the lower bound of delay of packets between partitions
+func partition(nodes []base.Node, count int) (map[base.Node]int, int, time.Duration) {
+	indexes := map[base.Node]int{}
+	var all []base.Node
+	var visit func(node base.Node)
+	visit = func(node base.Node) {
+		if _, ok := indexes[node]; ok {
+			return
+		}
+		indexes[node] = len(all)
+		all = append(all, node)
+		for _, next := range node.GetNext() {
+			visit(next)
+		}
+	}
+	for _, node := range nodes {
+		visit(node)
+	}
+	parents := make([]int, len(all))
+	for i := range parents {
+		parents[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parents[i] != i {
+			parents[i] = find(parents[i])
+		}
+		return parents[i]
+	}
+	type edge struct {
+		from, to int
+		delay    time.Duration
+	}
+	var cuts []edge
+	for i, node := range all {
+		for _, next := range node.GetNext() {
+			j := indexes[next]
+			if delayer, ok := node.(base.Delayer); ok {
+				if delay, ok := delayer.MinDelay(); ok && delay > 0 {
+					cuts = append(cuts, edge{from: i, to: j, delay: delay})
+					continue
+				}
+			}
+			parents[find(i)] = find(j)
+		}
+		// internal nodes are simulated along with the composite node
+		if composite, ok := node.(base.Composite); ok {
+			for _, member := range composite.Members() {
+				parents[find(i)] = find(indexes[member])
+			}
+		}
+	}
+	components := map[int][]int{}
+	var roots []int
+	for i := range all {
+		root := find(i)
+		if _, ok := components[root]; !ok {
+			roots = append(roots, root)
+		}
+		components[root] = append(components[root], i)
+	}
+	// assign the largest component to the least loaded partition first, to balance the partitions
+	sort.SliceStable(roots, func(i, j int) bool {
+		return len(components[roots[i]]) > len(components[roots[j]])
+	})
+	if count > len(roots) {
+		count = len(roots)
+	}
+	if count < 1 {
+		count = 1
+	}
+	loads := make([]int, count)
+	assignments := make([]int, len(all))
+	for _, root := range roots {
+		target := 0
+		for i, load := range loads {
+			if load < loads[target] {
+				target = i
+			}
+		}
+		for _, i := range components[root] {
+			assignments[i] = target
+		}
+		loads[target] += len(components[root])
+	}
+	lookahead := time.Duration(math.MaxInt64)
+	for _, cut := range cuts {
+		if assignments[cut.from] != assignments[cut.to] && cut.delay < lookahead {
+			lookahead = cut.delay
+		}
+	}
+	result := make(map[base.Node]int, len(all))
+	for i, node := range all {
+		result[node] = assignments[i]
+	}
+	return result, count, lookahead
+}
+
+// record of a handled event, the rank is the order of the event handled in the sequential simulation
+type record struct {
+	rank uint64
+}
+
+// entry is an event generated as the index-th event by the event of the parent record
+// in the sequential simulation, events are sorted by time, priority and then the order of enqueue,
+// which is exactly the order of (time, priority, rank of parent, index), so that the parallel simulation has the same order
+// the time is kept since the event may be recycled once handled, while the entry is still compared until merged
+type entry struct {
+	event    base.Event
+	time     time.Time
+	priority base.Priority
+	parent   *record
+	index    int
+}
+
+func newEntry(event base.Event, parent *record, index int) *entry {
+	e := &entry{event: event, time: event.Time(), parent: parent, index: index}
+	if p, ok := event.(base.Prioritized); ok {
+		e.priority = p.Priority()
+	}
+	return e
+}
+
+func (e *entry) less(o *entry) bool {
+	if !e.time.Equal(o.time) {
+		return e.time.Before(o.time)
+	}
+	if e.priority != o.priority {
+		return e.priority < o.priority
+	}
+	if e.parent.rank != o.parent.rank {
+		return e.parent.rank < o.parent.rank
+	}
+	return e.index < o.index
+}
+
+func (e *entry) cancelled() bool {
+	c, ok := e.event.(base.Cancellable)
+	return ok && c.Cancelled()
+}
+
+type entryHeap []*entry
+
+func (h entryHeap) Len() int {
+	return len(h)
+}
+
+func (h entryHeap) Less(i, j int) bool {
+	return h[i].less(h[j])
+}
+
+func (h entryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *entryHeap) Push(x interface{}) {
+	*h = append(*h, x.(*entry))
+}
+
+func (h *entryHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return x
+}
+
+// peek the first event not cancelled, nil if empty
+func (h *entryHeap) peek() *entry {
+	for h.Len() > 0 {
+		if !(*h)[0].cancelled() {
+			return (*h)[0]
+		}
+		heap.Pop(h)
+	}
+	return nil
+}
+
+// handled is an entry handled in the current window, with the record of it
+type handled struct {
+	entry  *entry
+	record *record
+}
+
+// worker simulates a partition of the network
+type worker struct {
+	engine  *engine
+	index   int
+	queue   entryHeap
+	handled []handled // entries handled in the current window, in order
+	outbox  []*entry  // entries generated for other partitions in the current window
+	current *entry
+	err     error
+}
+
+// process events before the limit, and also before the barrier if not nil
+func (w *worker) process(offset uint64, limit time.Time, end time.Time, barrier *entry) {
+	defer func() {
+		if r := recover(); r != nil {
+			var event base.Event
+			if w.current != nil {
+				event = w.current.event
+			}
+			w.err = newEventError(event, r, debug.Stack())
+		}
+	}()
+	for {
+		e := w.queue.peek()
+		if e == nil || !e.time.Before(limit) || (barrier != nil && !e.less(barrier)) {
+			return
+		}
+		heap.Pop(&w.queue)
+		r := &record{rank: offset + uint64(len(w.handled))}
+		w.handled = append(w.handled, handled{entry: e, record: r})
+		w.current = e
+		for i, event := range base.Act(e.event, e.time) {
+			child := newEntry(event, r, i)
+			target, ok := w.engine.locate(event)
+			if !ok || target == w.index {
+				heap.Push(&w.queue, child)
+				continue
+			}
+			if event.Time().Before(end) {
+				panic(fmt.Errorf("event at %s for another partition is earlier than the lookahead %s allows", event.Time(), w.engine.lookahead))
+			}
+			w.outbox = append(w.outbox, child)
+		}
+		base.Recycle(e.event)
+	}
+}
+
+// engine simulates partitions of the network in parallel, using the lookahead of partitions to synchronize
+type engine struct {
+	workers   []*worker
+	global    entryHeap // events not bound to any node, handled when all partitions are synchronized
+	locations map[base.Node]int
+	lookahead time.Duration
+	rank      uint64 // count of ranks assigned
+}
+
+// locate return the partition index of the node bound to the given event, false if not bound
+func (e *engine) locate(event base.Event) (int, bool) {
+	node := event.Node()
+	if node == nil {
+		return 0, false
+	}
+	index, ok := e.locations[node]
+	return index, ok
+}
+
+// dispatch entries generated outside partitions to where they belong
+func (e *engine) dispatch(entries ...*entry) {
+	for _, entry := range entries {
+		if index, ok := e.locate(entry.event); ok {
+			heap.Push(&e.workers[index].queue, entry)
+		} else {
+			heap.Push(&e.global, entry)
+		}
+	}
+}
+
+// root dispatch events not generated by any event, such as events injected
+func (e *engine) root(events []base.Event) {
+	if len(events) == 0 {
+		return
+	}
+	parent := &record{rank: e.rank}
+	e.rank++
+	for i, event := range events {
+		e.dispatch(newEntry(event, parent, i))
+	}
+}
+
+// head return the first entry of all partitions and the global queue, nil if no events remain
+func (e *engine) head() *entry {
+	result := e.global.peek()
+	for _, w := range e.workers {
+		if h := w.queue.peek(); h != nil && (result == nil || h.less(result)) {
+			result = h
+		}
+	}
+	return result
+}
+
+// merge entries handled by partitions in the order of the sequential simulation, and assign the final rank to them
+// since the parent of an entry is always handled before it, the parent has the final rank when compared
+// return the time of the last entry handled, zero if nothing handled
+func (e *engine) merge() time.Time {
+	var last time.Time
+	positions := make([]int, len(e.workers))
+	for {
+		var min *worker
+		for i, w := range e.workers {
+			if positions[i] < len(w.handled) && (min == nil || w.handled[positions[i]].entry.less(min.handled[positions[min.index]].entry)) {
+				min = w
+			}
+		}
+		if min == nil {
+			break
+		}
+		h := min.handled[positions[min.index]]
+		h.record.rank = e.rank
+		e.rank++
+		last = h.entry.time
+		positions[min.index]++
+	}
+	for _, w := range e.workers {
+		w.handled = w.handled[:0]
+	}
+	return last
+}
+
+// handleGlobal handle the given global event when all partitions are synchronized
+func (e *engine) handleGlobal(s *simulation, global *entry) {
+	s.current = global.event
+	r := &record{rank: e.rank}
+	e.rank++
+	for i, event := range base.Act(global.event, global.time) {
+		e.dispatch(newEntry(event, r, i))
+	}
+	base.Recycle(global.event)
+}
+
+// parallelLoop same to loop, but simulate partitions of the network in parallel in virtual time
+func (s *simulation) parallelLoop() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = newEventError(s.current, r, debug.Stack())
+		}
+	}()
+	n := s.network
+	locations, count, lookahead := partition(flatten(n.nodes), s.config.partitions)
+	e := &engine{locations: locations, lookahead: lookahead}
+	for i := 0; i < count; i++ {
+		e.workers = append(e.workers, &worker{engine: e, index: i})
+	}
+	var initial []base.Event
+	for event, ok := s.queue.Dequeue(); ok; event, ok = s.queue.Dequeue() {
+		initial = append(initial, event)
+	}
+	e.root(initial)
+	defer func() {
+		// put events remaining back to the queue in order, so that they are reported
+		var remaining []*entry
+		for _, w := range e.workers {
+			remaining = append(remaining, w.queue...)
+		}
+		remaining = append(remaining, e.global...)
+		sort.Slice(remaining, func(i, j int) bool {
+			return remaining[i].less(remaining[j])
+		})
+		for _, entry := range remaining {
+			if !entry.cancelled() {
+				s.queue.Enqueue(entry.event)
+			}
+		}
+	}()
+	for !s.stopped.Load() {
+		var injected []base.Event
+		n.buffer.Reduce(func(event base.Event) {
+			injected = append(injected, event)
+		})
+		e.root(injected)
+		head := e.head()
+		if head == nil {
+			if !s.config.keepAlive {
+				break
+			}
+			// nothing happens until events injected or stopped
+			<-n.buffer.Signal()
+			continue
+		}
+		start := head.time
+		if start.After(s.now) {
+			s.now = start
+		}
+		if s.now.After(s.deadline) {
+			break
+		}
+		end := start.Add(lookahead)
+		if end.Before(start) {
+			end = time.Unix(0, math.MaxInt64)
+		}
+		limit := end
+		if deadline := s.deadline.Add(time.Nanosecond); deadline.Before(limit) {
+			limit = deadline
+		}
+		barrier := e.global.peek()
+		if len(e.workers) == 1 {
+			e.workers[0].process(e.rank, limit, end, barrier)
+		} else {
+			wg := &sync.WaitGroup{}
+			for _, w := range e.workers {
+				wg.Add(1)
+				go func(w *worker) {
+					defer wg.Done()
+					w.process(e.rank, limit, end, barrier)
+				}(w)
+			}
+			wg.Wait()
+		}
+		for _, w := range e.workers {
+			if w.err != nil {
+				return w.err
+			}
+		}
+		if last := e.merge(); last.After(s.now) {
+			s.now = last
+		}
+		for _, w := range e.workers {
+			for _, entry := range w.outbox {
+				heap.Push(&e.workers[e.locations[entry.event.Node()]].queue, entry)
+			}
+			w.outbox = w.outbox[:0]
+		}
+		if barrier != nil && barrier.time.Before(limit) {
+			heap.Pop(&e.global)
+			if barrier.time.After(s.now) {
+				s.now = barrier.time
+			}
+			e.handleGlobal(s, barrier)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package ns_x
+
+import (
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/math"
+	"github.com/bytedance/ns-x/v2/node"
+	"github.com/bytedance/ns-x/v2/tick"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const sites = 4
+
+// ring build sites connected as a ring by lossy channels with fixed delay, each site has a host receiving all packets
+func ring(t *testing.T) (*Network, []*node.EndpointNode) {
+	builder := NewBuilder()
+	for i := 0; i < sites; i++ {
+		name := strconv.Itoa(i)
+		builder.Chain().
+			NodeWithName("host"+name, node.NewEndpointNode()).
+			NodeWithName("gather"+name, node.NewGatherNode()).
+			NodeWithName("broadcast"+name, node.NewBroadcastNode()).
+			Node(node.NewChannelNode()).
+			NodeOfName("host" + name)
+	}
+	for i := 0; i < sites; i++ {
+		random := rand.New(rand.NewSource(int64(i)))
+		builder.Chain().
+			NodeOfName("broadcast" + strconv.Itoa(i)).
+			Node(node.NewChannelNode(node.WithDescribedDelay(math.NewFixedDelayModel(time.Millisecond)), node.WithDescribedLoss(math.NewRandomLossModel(0.1, random)))).
+			NodeOfName("gather" + strconv.Itoa((i+1)%sites))
+	}
+	network, nodes, err := builder.Build()
+	assert.NoError(t, err)
+	hosts := make([]*node.EndpointNode, sites)
+	for i := range hosts {
+		hosts[i] = nodes["host"+strconv.Itoa(i)].(*node.EndpointNode)
+	}
+	return network, hosts
+}
+
+func TestPartition(t *testing.T) {
+	network, hosts := ring(t)
+	locations, count, lookahead := partition(network.Nodes(), 8)
+	assert.Equal(t, sites, count)
+	assert.Equal(t, time.Millisecond, lookahead)
+	for i, host := range hosts {
+		for j := 0; j < i; j++ {
+			assert.NotEqual(t, locations[hosts[j]], locations[host])
+		}
+	}
+	_, count, _ = partition(network.Nodes(), 2)
+	assert.Equal(t, 2, count)
+}
+
+func TestParallel(t *testing.T) {
+	now := time.Now()
+	run := func(configs ...Config) ([][]trace, *RunResult) {
+		network, hosts := ring(t)
+		traces := make([][]trace, sites)
+		events := make([]base.Event, 0)
+		for i, host := range hosts {
+			i, host := i, host
+			host.Receive(func(packet base.Packet, now time.Time) []base.Event {
+				id := int(packet.(base.RawPacket)[0])
+				traces[i] = append(traces[i], trace{packet: id, target: i, time: now})
+				if id%3 == 0 && id < 200 {
+					return []base.Event{host.Send(base.RawPacket{byte(id + 1)}, now.Add(time.Microsecond))}
+				}
+				return nil
+			})
+			for j := 0; j < 8; j++ {
+				events = append(events, host.Send(base.RawPacket{byte(i*8 + j)}, now.Add(time.Duration(j)*time.Microsecond)))
+			}
+		}
+		configs = append(configs, WithVirtualTime())
+		assert.NoError(t, network.Run(events, tick.NewStepClock(now, time.Millisecond), 10*time.Millisecond, configs...))
+		result, err := network.Wait()
+		assert.NoError(t, err)
+		return traces, result
+	}
+	expected, result := run()
+	assert.NotEmpty(t, expected[0])
+	configs := []Config{
+		WithParallel(2),
+		WithParallel(sites),
+		WithAdaptiveBuckets(),
+		WithScheduler(func() base.EventScheduler { return base.NewHeapScheduler() }),
+		WithScheduler(func() base.EventScheduler { return base.NewTimingWheel(time.Microsecond, 8, 4) }),
+		WithScheduler(func() base.EventScheduler { return base.NewLadderQueue() }),
+	}
+	for _, config := range configs {
+		traces, r := run(config)
+		assert.Equal(t, expected, traces)
+		assert.Equal(t, result.Reason, r.Reason)
+		assert.Equal(t, result.End, r.End)
+		assert.Equal(t, len(result.Remaining), len(r.Remaining))
+	}
+}
+
+func TestParallelSchedule(t *testing.T) {
+	now := time.Now()
+	network, hosts := ring(t)
+	received := 0
+	hosts[1].Receive(func(packet base.Packet, now time.Time) []base.Event {
+		received++
+		return nil
+	})
+	var fired []time.Time
+	network.Schedule(now.Add(time.Second), func(t time.Time) []base.Event {
+		fired = append(fired, t)
+		return []base.Event{hosts[0].Send(base.RawPacket{0}, t)}
+	})
+	assert.NoError(t, network.Run(nil, tick.NewStepClock(now, time.Millisecond), time.Minute, WithParallel(sites)))
+	result, err := network.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, Drained, result.Reason)
+	assert.Equal(t, []time.Time{now.Add(time.Second)}, fired)
+	assert.NotZero(t, received)
+}
@@ -0,0 +1,241 @@
+package ns_x
+
+import (
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/node"
+	"sort"
+)
+
+// queries on the network follow current connections of nodes, internal nodes of base.Composite nodes are not included
+
+// NodeOfName return the node with the given name in the builder, nil if none
+func (n *Network) NodeOfName(name string) base.Node {
+	return n.names[name]
+}
+
+// NodeOfID return the node with the given id assigned by the builder, which is the index in Nodes, nil if none
+func (n *Network) NodeOfID(id int) base.Node {
+	if id < 0 || id >= len(n.nodes) {
+		return nil
+	}
+	return n.nodes[id]
+}
+
+// NameOf return the name of the node in the builder, empty if unnamed
+func (n *Network) NameOf(node base.Node) string {
+	return n.nodeName[node]
+}
+
+// IDOf return the id of the node, which is the index in Nodes, -1 if not a node of the network
+func (n *Network) IDOf(node base.Node) int {
+	if id, ok := n.ids[node]; ok {
+		return id
+	}
+	return -1
+}
+
+// Previous return nodes of the network whose next nodes include the given node, in the order of id
+func (n *Network) Previous(node base.Node) []base.Node {
+	var result []base.Node
+	for _, p := range n.nodes {
+		if contains(p.GetNext(), node) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// ReachableFrom return nodes reachable from the given node along connections in breadth first order,
+// not including the node itself unless it's on a cycle
+func (n *Network) ReachableFrom(node base.Node) []base.Node {
+	var result []base.Node
+	visited := map[base.Node]bool{}
+	queue := []base.Node{node}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range current.GetNext() {
+			if !visited[next] {
+				visited[next] = true
+				result = append(result, next)
+				queue = append(queue, next)
+			}
+		}
+	}
+	return result
+}
+
+// Reachable whether packets from the given node can reach the target along connections, true if they are the same node
+func (n *Network) Reachable(from, to base.Node) bool {
+	return from == to || contains(n.ReachableFrom(from), to)
+}
+
+// Paths return simple paths from the given node to the target in depth first order, including both ends
+// paths never pass through other endpoints, which don't forward packets
+// at most limit paths are returned, all paths if limit not positive, which may be exponentially many
+func (n *Network) Paths(from, to base.Node, limit int) [][]base.Node {
+	return paths(from, to, limit, base.Node.GetNext)
+}
+
+// passing whether packets from the given node may pass through the node, false for other endpoints
+func passing(from, n base.Node) bool {
+	_, endpoint := n.(*node.EndpointNode)
+	return !endpoint || n == from
+}
+
+// paths find simple paths along connections given by next, same to Network.Paths
+func paths(from, to base.Node, limit int, next func(node base.Node) []base.Node) [][]base.Node {
+	var result [][]base.Node
+	onPath := map[base.Node]bool{}
+	var path []base.Node
+	var visit func(node base.Node) bool // return false once enough paths found
+	visit = func(node base.Node) bool {
+		path = append(path, node)
+		onPath[node] = true
+		defer func() {
+			path = path[:len(path)-1]
+			onPath[node] = false
+		}()
+		if node == to {
+			result = append(result, append([]base.Node(nil), path...))
+			return limit <= 0 || len(result) < limit
+		}
+		if !passing(from, node) {
+			return true
+		}
+		for _, following := range next(node) {
+			if !onPath[following] && !visit(following) {
+				return false
+			}
+		}
+		return true
+	}
+	visit(from)
+	return result
+}
+
+// ShortestPaths return at most k shortest simple paths in hops from the given node to the target by Yen's algorithm,
+// including both ends, shorter paths first, and paths of the same length in depth first order
+// paths never pass through other endpoints, which don't forward packets
+func (n *Network) ShortestPaths(from, to base.Node, k int) [][]base.Node {
+	return cheapestPaths(from, to, k, func(node base.Node) int64 {
+		return 1
+	}, base.Node.GetNext)
+}
+
+// cheapestPaths return at most k simple paths with the least sum of weights of nodes from the given node to the target
+// by Yen's algorithm, along connections given by next, cheaper paths first, paths never pass through other endpoints
+func cheapestPaths(from, to base.Node, k int, weight func(node base.Node) int64, next func(node base.Node) []base.Node) [][]base.Node {
+	first := cheapestPath(from, to, weight, next, nil, nil)
+	if first == nil || k <= 0 {
+		return nil
+	}
+	cost := func(path []base.Node) int64 {
+		result := int64(0)
+		for _, node := range path {
+			result += weight(node)
+		}
+		return result
+	}
+	result := [][]base.Node{first}
+	var candidates [][]base.Node
+	for len(result) < k {
+		last := result[len(result)-1]
+		for i := 0; i < len(last)-1; i++ {
+			root := last[:i+1]
+			// avoid edges leaving the spur node taken by found paths with the same root, and nodes of the root
+			removedEdges := map[[2]base.Node]bool{}
+			for _, p := range result {
+				if len(p) > i+1 && equalPath(p[:i+1], root) {
+					removedEdges[[2]base.Node{p[i], p[i+1]}] = true
+				}
+			}
+			removedNodes := map[base.Node]bool{}
+			for _, node := range root[:i] {
+				removedNodes[node] = true
+			}
+			spur := cheapestPath(last[i], to, weight, next, removedNodes, removedEdges)
+			if spur == nil {
+				continue
+			}
+			candidate := append(append([]base.Node(nil), root[:i]...), spur...)
+			if !containsPath(candidates, candidate) && !containsPath(result, candidate) {
+				candidates = append(candidates, candidate)
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return cost(candidates[i]) < cost(candidates[j])
+		})
+		result = append(result, candidates[0])
+		candidates = candidates[1:]
+	}
+	return result
+}
+
+// cheapestPath find the path with the least sum of weights by Dijkstra's algorithm, avoiding the given nodes and edges,
+// nil if none, nodes of the same distance are visited in the order found, so that it's a breadth first search in hops
+func cheapestPath(from, to base.Node, weight func(node base.Node) int64, next func(node base.Node) []base.Node, removedNodes map[base.Node]bool, removedEdges map[[2]base.Node]bool) []base.Node {
+	distance := map[base.Node]int64{from: weight(from)}
+	previous := map[base.Node]base.Node{from: nil}
+	visited := map[base.Node]bool{}
+	frontier := []base.Node{from}
+	for len(frontier) > 0 {
+		index := 0
+		for i, n := range frontier {
+			if distance[n] < distance[frontier[index]] {
+				index = i
+			}
+		}
+		current := frontier[index]
+		frontier = append(frontier[:index], frontier[index+1:]...)
+		if current == to {
+			var path []base.Node
+			for n := to; n != nil; n = previous[n] {
+				path = append([]base.Node{n}, path...)
+			}
+			return path
+		}
+		visited[current] = true
+		if !passing(from, current) {
+			continue
+		}
+		for _, following := range next(current) {
+			if visited[following] || removedNodes[following] || removedEdges[[2]base.Node{current, following}] {
+				continue
+			}
+			d := distance[current] + weight(following)
+			if old, ok := distance[following]; !ok || d < old {
+				if !ok {
+					frontier = append(frontier, following)
+				}
+				distance[following] = d
+				previous[following] = current
+			}
+		}
+	}
+	return nil
+}
+
+func equalPath(a, b []base.Node) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsPath(paths [][]base.Node, path []base.Node) bool {
+	for _, p := range paths {
+		if equalPath(p, path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package ns_x
+
+import (
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/math"
+	"github.com/bytedance/ns-x/v2/node"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestQuery(t *testing.T) {
+	network, nodes, err := NewBuilder().
+		Chain().
+		NodeWithName("sender", node.NewEndpointNode()).
+		NodeWithName("s", node.NewBroadcastNode()).
+		NodeWithName("a", node.NewBroadcastNode()).
+		NodeWithName("t", node.NewEndpointNode()).
+		Chain().
+		NodeOfName("s").
+		NodeWithName("b", node.NewBroadcastNode()).
+		NodeWithName("c", node.NewChannelNode(node.WithDescribedDelay(math.NewFixedDelayModel(time.Millisecond)))).
+		NodeOfName("t").
+		Chain().
+		NodeOfName("a").
+		NodeOfName("b").
+		Build()
+	assert.NoError(t, err)
+	path := func(names ...string) []base.Node {
+		result := make([]base.Node, len(names))
+		for i, name := range names {
+			result[i] = nodes[name]
+		}
+		return result
+	}
+	s, a, tt := nodes["s"], nodes["a"], nodes["t"]
+
+	assert.Same(t, a, network.NodeOfName("a"))
+	assert.Nil(t, network.NodeOfName("d"))
+	assert.Same(t, a, network.NodeOfID(2))
+	assert.Nil(t, network.NodeOfID(6))
+	assert.Equal(t, "c", network.NameOf(network.NodeOfID(5)))
+	assert.Equal(t, 3, network.IDOf(tt))
+	assert.Equal(t, -1, network.IDOf(node.NewEndpointNode()))
+
+	assert.Equal(t, path("a", "c"), network.Previous(tt))
+	assert.Equal(t, path("s", "a", "b", "t", "c"), network.ReachableFrom(nodes["sender"]))
+	assert.True(t, network.Reachable(s, tt))
+	assert.True(t, network.Reachable(tt, tt))
+	assert.False(t, network.Reachable(tt, s))
+
+	assert.Equal(t, [][]base.Node{path("s", "a", "t"), path("s", "a", "b", "c", "t"), path("s", "b", "c", "t")}, network.Paths(s, tt, 0))
+	assert.Equal(t, [][]base.Node{path("s", "a", "t")}, network.Paths(s, tt, 1))
+	assert.Nil(t, network.Paths(tt, s, 0))
+	assert.Equal(t, [][]base.Node{path("s", "a", "t"), path("s", "b", "c", "t")}, network.ShortestPaths(s, tt, 2))
+	assert.Equal(t, [][]base.Node{path("s", "a", "t"), path("s", "b", "c", "t"), path("s", "a", "b", "c", "t")}, network.ShortestPaths(s, tt, 10))
+	assert.Nil(t, network.ShortestPaths(tt, s, 3))
+}
+
+func TestPathsThroughEndpoints(t *testing.T) {
+	// packets received by the relay endpoint are not forwarded to the receiver
+	network, nodes, err := NewBuilder().
+		Chain().
+		NodeWithName("sender", node.NewEndpointNode()).
+		NodeWithName("split", node.NewBroadcastNode()).
+		NodeWithName("relay", node.NewEndpointNode()).
+		NodeWithName("receiver", node.NewEndpointNode()).
+		Chain().
+		NodeOfName("split").
+		NodeWithName("channel", node.NewChannelNode(node.WithDescribedDelay(math.NewFixedDelayModel(time.Millisecond)))).
+		NodeOfName("receiver").
+		Build()
+	assert.NoError(t, err)
+	sender, relay, receiver := nodes["sender"], nodes["relay"], nodes["receiver"]
+	expected := [][]base.Node{{sender, nodes["split"], nodes["channel"], receiver}}
+	assert.Equal(t, expected, network.Paths(sender, receiver, 0))
+	assert.Equal(t, expected, network.ShortestPaths(sender, receiver, 2))
+	assert.Equal(t, [][]base.Node{{relay, receiver}}, network.Paths(relay, receiver, 0))
+	result, err := network.Analyze("sender", "receiver", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(result))
+	assert.Equal(t, time.Millisecond, result[0].MinDelay)
+}
@@ -0,0 +1,298 @@
+package ns_x
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/math"
+	"github.com/bytedance/ns-x/v2/node"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// NodeConstructor create a node of a type named in topology files with the given options
+type NodeConstructor func(options ...node.Option) base.Node
+
+// OptionConstructor create an option of nodes from the parameters in topology files
+// random is shared by all models of the topology, seeded by the topology so that runs are reproducible
+type OptionConstructor func(params Params, random *rand.Rand) (node.Option, error)
+
+// registry of node types and options used by topology files
+var registry = struct {
+	lock    sync.RWMutex
+	nodes   map[string]NodeConstructor
+	options map[string]OptionConstructor
+}{
+	nodes:   map[string]NodeConstructor{},
+	options: map[string]OptionConstructor{},
+}
+
+// RegisterNode register the constructor of the node type with the given name, overwrite if already registered
+// such as a user-defined node, so that it can be used in topology files
+func RegisterNode(name string, constructor NodeConstructor) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	registry.nodes[name] = constructor
+}
+
+// RegisterOption register the constructor of the option with the given name, overwrite if already registered
+func RegisterOption(name string, constructor OptionConstructor) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	registry.options[name] = constructor
+}
+
+// NodeTypes return names of node types registered, sorted
+func NodeTypes() []string {
+	registry.lock.RLock()
+	defer registry.lock.RUnlock()
+	result := make([]string, 0, len(registry.nodes))
+	for name := range registry.nodes {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func lookupNode(name string) (NodeConstructor, bool) {
+	registry.lock.RLock()
+	defer registry.lock.RUnlock()
+	constructor, ok := registry.nodes[name]
+	return constructor, ok
+}
+
+func lookupOption(name string) (OptionConstructor, bool) {
+	registry.lock.RLock()
+	defer registry.lock.RUnlock()
+	constructor, ok := registry.options[name]
+	return constructor, ok
+}
+
+// Params are parameters of an option in topology files
+type Params map[string]interface{}
+
+// Has whether the parameter with the given key is specified
+func (p Params) Has(key string) bool {
+	_, ok := p[key]
+	return ok
+}
+
+// String return the parameter as a string
+func (p Params) String(key string) (string, error) {
+	value, ok := p[key]
+	if !ok {
+		return "", fmt.Errorf("missing parameter %s", key)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("parameter %s is not a string: %v", key, value)
+	}
+	return s, nil
+}
+
+// Float return the parameter as a float
+func (p Params) Float(key string) (float64, error) {
+	value, ok := p[key]
+	if !ok {
+		return 0, fmt.Errorf("missing parameter %s", key)
+	}
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	}
+	return 0, fmt.Errorf("parameter %s is not a number: %v", key, value)
+}
+
+// Int return the parameter as an integer
+func (p Params) Int(key string) (int64, error) {
+	value, ok := p[key]
+	if !ok {
+		return 0, fmt.Errorf("missing parameter %s", key)
+	}
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v), nil
+		}
+	}
+	return 0, fmt.Errorf("parameter %s is not an integer: %v", key, value)
+}
+
+// Duration return the parameter as a duration, written like "10ms"
+func (p Params) Duration(key string) (time.Duration, error) {
+	s, err := p.String(key)
+	if err != nil {
+		return 0, err
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("parameter %s is not a duration: %v", key, err)
+	}
+	return d, nil
+}
+
+func init() {
+	RegisterNode("endpoint", func(options ...node.Option) base.Node { return node.NewEndpointNode(options...) })
+	RegisterNode("channel", func(options ...node.Option) base.Node { return node.NewChannelNode(options...) })
+	RegisterNode("restrict", func(options ...node.Option) base.Node { return node.NewRestrictNode(options...) })
+	RegisterNode("gather", func(options ...node.Option) base.Node { return node.NewGatherNode(options...) })
+	RegisterNode("scatter", func(options ...node.Option) base.Node { return node.NewScatterNode(options...) })
+	RegisterNode("broadcast", func(options ...node.Option) base.Node { return node.NewBroadcastNode(options...) })
+	RegisterOption("loss", lossOption)
+	RegisterOption("delay", delayOption)
+	RegisterOption("reorder", reorderOption)
+	RegisterOption("pps", limitOption(node.WithPPSLimit))
+	RegisterOption("bps", limitOption(node.WithBPSLimit))
+	RegisterOption("route", routeOption)
+}
+
+// lossOption: model random with possibility, or model gilbert with g2b, b2g, loss_good and loss_bad
+func lossOption(params Params, random *rand.Rand) (node.Option, error) {
+	model, err := params.String("model")
+	if err != nil {
+		return nil, err
+	}
+	switch model {
+	case "random":
+		possibility, err := params.Float("possibility")
+		if err != nil {
+			return nil, err
+		}
+		return node.WithDescribedLoss(math.NewRandomLossModel(possibility, random)), nil
+	case "gilbert":
+		var values [4]float64
+		for i, key := range []string{"g2b", "b2g", "loss_good", "loss_bad"} {
+			if values[i], err = params.Float(key); err != nil {
+				return nil, err
+			}
+		}
+		return node.WithDescribedLoss(math.NewGilbertLossModel(values[0], values[1], values[2], values[3], random)), nil
+	}
+	return nil, errors.New("unknown loss model " + model)
+}
+
+// delayOption: model fixed with delay, normal with average and sigma, uniform with average, or pareto with min and alpha
+func delayOption(params Params, random *rand.Rand) (node.Option, error) {
+	model, err := params.String("model")
+	if err != nil {
+		return nil, err
+	}
+	switch model {
+	case "fixed":
+		delay, err := params.Duration("delay")
+		if err != nil {
+			return nil, err
+		}
+		return node.WithDescribedDelay(math.NewFixedDelayModel(delay)), nil
+	case "normal":
+		average, err := params.Duration("average")
+		if err != nil {
+			return nil, err
+		}
+		sigma, err := params.Duration("sigma")
+		if err != nil {
+			return nil, err
+		}
+		return node.WithDescribedDelay(math.NewNormalDelayModel(average, sigma, random)), nil
+	case "uniform":
+		average, err := params.Duration("average")
+		if err != nil {
+			return nil, err
+		}
+		return node.WithDescribedDelay(math.NewUniformDelayModel(average, random)), nil
+	case "pareto":
+		min, err := params.Duration("min")
+		if err != nil {
+			return nil, err
+		}
+		alpha, err := params.Float("alpha")
+		if err != nil {
+			return nil, err
+		}
+		return node.WithDescribedDelay(math.NewParetoDelayModel(min, alpha, random)), nil
+	}
+	return nil, errors.New("unknown delay model " + model)
+}
+
+// reorderOption: model normal with delta, possibility and correlation, or model gap with gap in addition
+func reorderOption(params Params, random *rand.Rand) (node.Option, error) {
+	model, err := params.String("model")
+	if err != nil {
+		return nil, err
+	}
+	delta, err := params.Duration("delta")
+	if err != nil {
+		return nil, err
+	}
+	possibility, err := params.Float("possibility")
+	if err != nil {
+		return nil, err
+	}
+	correlation, err := params.Float("correlation")
+	if err != nil {
+		return nil, err
+	}
+	switch model {
+	case "normal":
+		return node.WithDescribedReorder(math.NewNormalReorderModel(delta, possibility, correlation, random)), nil
+	case "gap":
+		gap, err := params.Int("gap")
+		if err != nil {
+			return nil, err
+		}
+		if gap < 0 {
+			return nil, errors.New("gap cannot be negative")
+		}
+		return node.WithDescribedReorder(math.NewGapReorderModel(delta, possibility, correlation, uint(gap), random)), nil
+	}
+	return nil, errors.New("unknown reorder model " + model)
+}
+
+// limitOption: limit, and queue limit which is unlimited if not specified
+func limitOption(with func(limit float64, queue int64) node.Option) OptionConstructor {
+	return func(params Params, random *rand.Rand) (node.Option, error) {
+		limit, err := params.Float("limit")
+		if err != nil {
+			return nil, err
+		}
+		queue := int64(-1)
+		if params.Has("queue") {
+			if queue, err = params.Int("queue"); err != nil {
+				return nil, err
+			}
+		}
+		return with(limit, queue), nil
+	}
+}
+
+// routeOption: selector random, or round_robin
+func routeOption(params Params, random *rand.Rand) (node.Option, error) {
+	selector, err := params.String("selector")
+	if err != nil {
+		return nil, err
+	}
+	switch selector {
+	case "random":
+		return node.WithRouteSelector(func(packet base.Packet, nodes []base.Node) base.Node {
+			return nodes[random.Intn(len(nodes))]
+		}), nil
+	case "round_robin":
+		next := 0
+		return node.WithRouteSelector(func(packet base.Packet, nodes []base.Node) base.Node {
+			result := nodes[next%len(nodes)]
+			next++
+			return result
+		}), nil
+	}
+	return nil, errors.New("unknown route selector " + selector)
+}
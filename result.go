@@ -0,0 +1,49 @@
+package ns_x
+
+import (
+	"github.com/bytedance/ns-x/v2/base"
+	"time"
+)
+
+// StopReason indicates why a simulation finished
+type StopReason int
+
+const (
+	// Drained means no events remain in the event queue
+	Drained StopReason = iota
+	// Expired means the lifetime of the simulation is reached
+	Expired
+	// Cancelled means the simulation is stopped by Network.Stop or the context passed to Network.RunContext
+	Cancelled
+	// Failed means a panic raised when handling events, see the error returned by Network.Wait
+	Failed
+)
+
+func (r StopReason) String() string {
+	switch r {
+	case Drained:
+		return "drained"
+	case Expired:
+		return "expired"
+	case Cancelled:
+		return "cancelled"
+	case Failed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// RunResult describes a finished simulation
+type RunResult struct {
+	// Reason why the simulation finished
+	Reason StopReason
+	// Start time of the simulation in simulated clock
+	Start time.Time
+	// End time of the simulation in simulated clock
+	End time.Time
+	// Remaining events not handled when the simulation finished, sorted by time
+	Remaining []base.Event
+	// Lag is the statistics of lateness of events handled following the clock
+	Lag LagStats
+}
@@ -0,0 +1,213 @@
+package ns_x
+
+import (
+	"errors"
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/tick"
+	"go.uber.org/atomic"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// simulation holds the state of a single run of the network
+type simulation struct {
+	network   *Network
+	queue     base.EventScheduler
+	clock     tick.Clock
+	config    *config
+	stopped   *atomic.Bool
+	done      chan struct{}
+	start     time.Time
+	now       time.Time
+	deadline  time.Time
+	offset    time.Duration // total time paused in real clock, excluded from simulated clock
+	current   base.Event    // event being handled, used to report panics
+	lag       LagStats
+	tracer    tracer     // records or replays the trace, nil if neither
+	ids       uint64     // count of IDs of events assigned
+	causality *causality // exports the causality DAG, nil if not
+}
+
+// eventLoop Main polling loop of network
+func (s *simulation) eventLoop() {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	n := s.network
+	defer n.wg.Done()
+	defer n.running.Store(false)
+	defer close(s.done)
+	defer n.debugger.finish()
+	println("network main loop start at", s.now.String())
+	var err error
+	if s.config.partitions > 1 {
+		err = s.parallelLoop()
+	} else {
+		err = s.loop()
+	}
+	println("network main loop end at", s.now.String())
+	result := &RunResult{Reason: Drained, Start: s.start, End: s.now, Lag: s.lag}
+	if err != nil {
+		result.Reason = Failed
+	} else if s.stopped.Load() {
+		result.Reason = Cancelled
+	} else if s.now.After(s.deadline) {
+		result.Reason = Expired
+	}
+	if s.tracer != nil {
+		s.untrace()
+		if e := s.tracer.finish(); e != nil && err == nil {
+			result.Reason, err = Failed, e
+		}
+	}
+	if s.causality != nil {
+		if e := s.causality.finish(); e != nil && err == nil {
+			result.Reason, err = Failed, e
+		}
+	}
+	n.buffer.Reduce(s.queue.Enqueue)
+	for event, ok := s.queue.Dequeue(); ok; event, ok = s.queue.Dequeue() {
+		result.Remaining = append(result.Remaining, event)
+	}
+	n.result, n.err = result, err
+}
+
+// loop handle events until the simulation finished, panics raised when handling events are recovered as EventError
+func (s *simulation) loop() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = newEventError(s.current, r, debug.Stack())
+		}
+	}()
+	n := s.network
+	for !s.stopped.Load() && !s.now.After(s.deadline) {
+		n.buffer.Reduce(s.inject)
+		p, ok := s.queue.Peek()
+		if !ok {
+			if !s.config.keepAlive {
+				break
+			}
+			if s.config.virtualTime {
+				// nothing happens in virtual time until events injected or stopped
+				<-n.buffer.Signal()
+			} else {
+				s.now = s.clock().Add(-s.offset)
+			}
+			continue
+		}
+		t := p.Time()
+		if t.After(s.now) {
+			if s.config.virtualTime {
+				s.now = t
+			} else {
+				s.now = s.clock().Add(-s.offset)
+			}
+			continue
+		}
+		if n.debugger.active.Load() {
+			if n.debugger.checkpoint(s.now, p, s.stopped) {
+				if !s.config.virtualTime {
+					// exclude the time paused, so that the simulated clock resumes from where it paused
+					s.offset = s.clock().Sub(s.now)
+				}
+				if s.stopped.Load() {
+					continue
+				}
+				// the event may be cancelled while paused, peek again to skip it
+				if c, ok := p.(base.Cancellable); ok && c.Cancelled() {
+					continue
+				}
+			}
+		}
+		if !s.config.virtualTime {
+			if err := s.checkLag(p); err != nil {
+				return err
+			}
+		}
+		s.queue.Dequeue()
+		s.current = p
+		if s.tracer != nil {
+			if err := s.tracer.begin(p); err != nil {
+				return err
+			}
+		}
+		if s.causality != nil {
+			if err := s.causality.write(p); err != nil {
+				return err
+			}
+		}
+		events := base.Act(p, t)
+		if s.tracer != nil {
+			if err := s.tracer.end(p, events); err != nil {
+				return err
+			}
+		}
+		for _, event := range events {
+			s.schedule(p, event)
+		}
+		base.Recycle(p)
+	}
+	return nil
+}
+
+// schedule the event created by the parent, nil if from outside the simulation, and assign the cause of the event
+func (s *simulation) schedule(parent base.Event, event base.Event) {
+	if cause := base.CauseOf(event); cause != nil {
+		s.ids++
+		*cause = base.Cause{ID: s.ids}
+		if o, ok := event.(base.Originated); ok {
+			cause.Origin = o.Origin()
+		} else if parent != nil {
+			cause.Origin = parent.Node()
+		}
+		if parent != nil {
+			if c := base.CauseOf(parent); c != nil {
+				cause.Parent = c.ID
+			}
+		}
+	}
+	s.queue.Enqueue(event)
+}
+
+// inject the event from outside the simulation
+func (s *simulation) inject(event base.Event) {
+	s.schedule(nil, event)
+}
+
+// trace set up the tracer if recording or replaying, and hook decisions of nodes
+func (s *simulation) trace() error {
+	n := s.network
+	if s.config.record == nil && s.config.replay == nil {
+		return nil
+	}
+	if s.config.partitions > 1 {
+		return errors.New("record and replay are not supported in parallel")
+	}
+	if s.config.record != nil && s.config.replay != nil {
+		return errors.New("cannot record and replay at the same time")
+	}
+	if s.config.record != nil {
+		s.tracer = newRecorder(s.config.record, flatten(n.nodes), s.start)
+	} else {
+		replayer, err := newReplayer(s.config.replay, flatten(n.nodes), s.start)
+		if err != nil {
+			return err
+		}
+		s.tracer = replayer
+	}
+	for _, node := range flatten(n.nodes) {
+		if decider, ok := node.(base.Decider); ok {
+			decider.SetDecisionHook(s.tracer.decide)
+		}
+	}
+	return nil
+}
+
+// untrace remove the hook of decisions of nodes
+func (s *simulation) untrace() {
+	for _, node := range flatten(s.network.nodes) {
+		if decider, ok := node.(base.Decider); ok {
+			decider.SetDecisionHook(nil)
+		}
+	}
+}
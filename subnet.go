@@ -0,0 +1,25 @@
+package ns_x
+
+import (
+	"errors"
+	"github.com/bytedance/ns-x/v2/node"
+)
+
+// BuildSubnet build a node.SubnetNode of the sub-network described by the given function on a new builder, with the
+// ingress and egress nodes of the given names, so that the sub-network can be used as a single node in other builders
+// names described are only used to describe the sub-network, and not in the name map of the network built
+// return errors found when describing the sub-network, in which case nothing is built
+func BuildSubnet(describe func(builder Builder), ingress, egress string, options ...node.Option) (*node.SubnetNode, error) {
+	b := NewBuilder().(*builder)
+	describe(b)
+	in, _ := b.requireNodeByName(ingress)
+	out, _ := b.requireNodeByName(egress)
+	if len(b.errors) > 0 {
+		return nil, b.errors
+	}
+	if len(b.connections[out]) > 0 {
+		return nil, errors.New("egress node " + egress + " cannot have next nodes in the sub-network")
+	}
+	b.connect()
+	return node.NewSubnetNode(in, out, options...), nil
+}
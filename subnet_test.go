@@ -0,0 +1,75 @@
+package ns_x
+
+import (
+	"bytes"
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/math"
+	"github.com/bytedance/ns-x/v2/node"
+	"github.com/bytedance/ns-x/v2/tick"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSubnet(t *testing.T) {
+	subnet, err := BuildSubnet(func(builder Builder) {
+		builder.Chain().
+			NodeWithName("limit", node.NewRestrictNode(node.WithPPSLimit(1, -1))).
+			NodeWithName("access", node.NewChannelNode(node.WithDescribedDelay(math.NewFixedDelayModel(10*time.Millisecond))))
+	}, "limit", "access")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(subnet.Members()))
+	builder := NewBuilder().
+		Chain().
+		NodeWithName("sender", node.NewEndpointNode()).
+		NodeWithName("isp", subnet).
+		NodeWithName("receiver", node.NewEndpointNode())
+	assert.True(t, strings.Contains(builder.DOT(), `n1 [label="isp\nSubnetNode\nnodes: 2"];`))
+	network, nodes, err := builder.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(network.Nodes()))
+	assert.Equal(t, 3, len(nodes))
+	assert.Equal(t, []base.Node{nodes["receiver"]}, subnet.Egress().GetNext())
+
+	now := time.Unix(0, 0)
+	var received []time.Time
+	nodes["receiver"].(*node.EndpointNode).Receive(func(packet base.Packet, now time.Time) []base.Event {
+		received = append(received, now)
+		return nil
+	})
+	sender := nodes["sender"].(*node.EndpointNode)
+	buffer := &bytes.Buffer{}
+	events := []base.Event{sender.Send(base.RawPacket{}, now), sender.Send(base.RawPacket{}, now)}
+	assert.NoError(t, network.Run(events, tick.NewStepClock(now, time.Millisecond), time.Minute, WithVirtualTime(), WithCausality(buffer)))
+	_, err = network.Wait()
+	assert.NoError(t, err)
+	// the second packet is queued for a second by the limit in the subnet
+	assert.Equal(t, []time.Time{now.Add(10 * time.Millisecond), now.Add(1010 * time.Millisecond)}, received)
+	graph, err := ReadCausality(buffer)
+	assert.NoError(t, err)
+	// internal nodes are collapsed into the subnet node
+	assert.Equal(t, 0, len(graph.Filter(func(event *CausalEvent) bool {
+		return event.Node < 0 || event.Node >= 3
+	})))
+
+	// the limit keeps its state, so that run again later
+	received = nil
+	later := now.Add(time.Hour)
+	events = []base.Event{sender.Send(base.RawPacket{}, later)}
+	assert.NoError(t, network.Run(events, tick.NewStepClock(later, time.Millisecond), time.Minute, WithVirtualTime(), WithParallel(2)))
+	_, err = network.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{later.Add(10 * time.Millisecond)}, received)
+}
+
+func TestSubnetErrors(t *testing.T) {
+	_, err := BuildSubnet(func(builder Builder) {
+		builder.Chain().NodeWithName("a", node.NewGatherNode())
+	}, "a", "b")
+	assert.EqualError(t, err, "no node with name b")
+	_, err = BuildSubnet(func(builder Builder) {
+		builder.Chain().NodeWithName("a", node.NewGatherNode()).NodeWithName("b", node.NewGatherNode())
+	}, "b", "a")
+	assert.EqualError(t, err, "egress node a cannot have next nodes in the sub-network")
+}
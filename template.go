@@ -0,0 +1,123 @@
+package ns_x
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bytedance/ns-x/v2/base"
+)
+
+// Template describes a sub-topology within the scope of an instance, and exposes ports of the instance by Scope.Port
+// it's called once for each instance, so that nodes must be created on each call rather than shared by instances
+// parameters are given to each instance when instantiated, errors returned are recorded by the builder
+type Template func(scope Scope, params Params) error
+
+// Scope is a Builder within an instance of a template, where names of nodes, groups and links are prefixed by the name
+// of the instance and "/", such as "site1/router", so that names described never clash with other instances
+// nodes outside the instance can not be referred to, connect the instance through its ports instead
+type Scope interface {
+	Builder
+	// Port expose a port of the instance with the given name, which is a group from the in node to the out node of the given
+	// names in the scope, named after the instance like "site1/uplink", so that it can be inserted into chains by GroupOfName
+	// use the same node as in and out node for a port only receiving or sending packets
+	Port(name, inName, outName string) Scope
+}
+
+type scope struct {
+	*builder
+	prefix string
+}
+
+// name prefix the given name with the name of the instance, keep the empty name unnamed
+func (s *scope) name(name string) string {
+	if name == "" {
+		return ""
+	}
+	return s.prefix + name
+}
+
+func (b *builder) Instantiate(name string, template Template, params Params) Builder {
+	b.instantiate("", name, template, params)
+	return b
+}
+
+// instantiate the template in an instance with the given name, nested in the instance of the prefix
+// the template begins with a new chain, and the current chain is restored afterwards
+func (b *builder) instantiate(prefix, name string, template Template, params Params) {
+	current := b.current
+	defer func() {
+		b.current = current
+	}()
+	b.Chain()
+	if name == "" {
+		b.errors = append(b.errors, errors.New("name of instance cannot be empty string"))
+		return
+	}
+	if params == nil {
+		params = Params{}
+	}
+	if err := template(&scope{builder: b, prefix: prefix + name + "/"}, params); err != nil {
+		b.errors = append(b.errors, fmt.Errorf("instance %s: %w", prefix+name, err))
+	}
+}
+
+func (s *scope) Port(name, inName, outName string) Scope {
+	if name == "" {
+		s.errors = append(s.errors, errors.New("name of port cannot be empty string"))
+		return s
+	}
+	s.nameToGroup[s.name(name)] = &group{inName: s.name(inName), outName: s.name(outName)}
+	return s
+}
+
+func (s *scope) Chain() Builder {
+	s.builder.Chain()
+	return s
+}
+
+func (s *scope) Node(node base.Node) Builder {
+	s.builder.Node(node)
+	return s
+}
+
+func (s *scope) Group(inName, outName string) Builder {
+	s.builder.Group(s.name(inName), s.name(outName))
+	return s
+}
+
+func (s *scope) NodeWithName(name string, node base.Node) Builder {
+	s.builder.NodeWithName(s.name(name), node)
+	return s
+}
+
+func (s *scope) GroupWithName(name string, inName, outName string) Builder {
+	s.builder.GroupWithName(s.name(name), s.name(inName), s.name(outName))
+	return s
+}
+
+func (s *scope) NodeOfName(name string) Builder {
+	s.builder.NodeOfName(s.name(name))
+	return s
+}
+
+func (s *scope) GroupOfName(name string) Builder {
+	s.builder.GroupOfName(s.name(name))
+	return s
+}
+
+func (s *scope) Link(a, b string, spec LinkSpec) *Link {
+	return s.builder.Link(s.name(a), s.name(b), spec)
+}
+
+func (s *scope) Instantiate(name string, template Template, params Params) Builder {
+	s.builder.instantiate(s.prefix, name, template, params)
+	return s
+}
+
+func (s *scope) Summary() Builder {
+	s.builder.Summary()
+	return s
+}
+
+func (s *scope) Build() (*Network, map[string]base.Node, error) {
+	return nil, nil, errors.New("cannot build within an instance of a template")
+}
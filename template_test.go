@@ -0,0 +1,94 @@
+package ns_x
+
+import (
+	"errors"
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/math"
+	"github.com/bytedance/ns-x/v2/node"
+	"github.com/bytedance/ns-x/v2/tick"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// hopTemplate is a restrict node and a channel node delaying packets by the parameter "delay", exposed as the port "link"
+func hopTemplate(scope Scope, params Params) error {
+	delay, err := params.Duration("delay")
+	if err != nil {
+		return err
+	}
+	scope.Chain().
+		NodeWithName("restrict", node.NewRestrictNode(node.WithPPSLimit(1000, -1))).
+		NodeWithName("channel", node.NewChannelNode(node.WithDescribedDelay(math.NewFixedDelayModel(delay))))
+	scope.Port("link", "restrict", "channel")
+	return nil
+}
+
+// pathTemplate is two hops in a row, exposed as the port "link"
+func pathTemplate(scope Scope, params Params) error {
+	scope.Instantiate("first", hopTemplate, params).
+		Instantiate("second", hopTemplate, params).
+		Chain().
+		GroupOfName("first/link").
+		GroupOfName("second/link")
+	scope.Port("link", "first/restrict", "second/channel")
+	return nil
+}
+
+func TestTemplate(t *testing.T) {
+	builder := NewBuilder().
+		Instantiate("a", hopTemplate, Params{"delay": "10ms"}).
+		Instantiate("b", pathTemplate, Params{"delay": "20ms"}).
+		Chain().
+		NodeWithName("sender", node.NewEndpointNode()).
+		GroupOfName("a/link").
+		GroupOfName("b/link").
+		NodeWithName("receiver", node.NewEndpointNode())
+	network, nodes, err := builder.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, 8, len(network.Nodes()))
+	for _, name := range []string{"a/restrict", "a/channel", "b/first/restrict", "b/first/channel", "b/second/restrict", "b/second/channel"} {
+		assert.Contains(t, nodes, name)
+	}
+	assert.NotSame(t, nodes["b/first/channel"], nodes["b/second/channel"])
+
+	now := time.Unix(0, 0)
+	var received []time.Time
+	nodes["receiver"].(*node.EndpointNode).Receive(func(packet base.Packet, now time.Time) []base.Event {
+		received = append(received, now)
+		return nil
+	})
+	events := []base.Event{nodes["sender"].(*node.EndpointNode).Send(base.RawPacket{}, now)}
+	assert.NoError(t, network.Run(events, tick.NewStepClock(now, time.Millisecond), time.Minute, WithVirtualTime()))
+	_, err = network.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{now.Add(50 * time.Millisecond)}, received)
+}
+
+func TestTemplateKeepChain(t *testing.T) {
+	_, nodes, err := NewBuilder().
+		Chain().
+		NodeWithName("sender", node.NewEndpointNode()).
+		Instantiate("a", hopTemplate, Params{"delay": "10ms"}).
+		GroupOfName("a/link").
+		NodeWithName("receiver", node.NewEndpointNode()).
+		Build()
+	assert.NoError(t, err)
+	assert.Equal(t, []base.Node{nodes["a/restrict"]}, nodes["sender"].GetNext())
+	assert.Equal(t, []base.Node{nodes["receiver"]}, nodes["a/channel"].GetNext())
+}
+
+func TestTemplateErrors(t *testing.T) {
+	builder := NewBuilder().
+		Instantiate("a", hopTemplate, nil).
+		Instantiate("b", func(scope Scope, params Params) error {
+			scope.Chain().NodeOfName("sender")
+			return errors.New("failed")
+		}, nil)
+	_, _, err := builder.Build()
+	errs, ok := err.(Errors)
+	assert.True(t, ok)
+	assert.Equal(t, "instance a: missing parameter delay", errs[0].Error())
+	assert.Equal(t, "no node with name b/sender", errs[1].Error())
+	assert.Equal(t, "instance b: failed", errs[2].Error())
+}
@@ -0,0 +1,194 @@
+package ns_x
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/node"
+	"gopkg.in/yaml.v3"
+	"io"
+	"math/rand"
+	"os"
+)
+
+// Topology is the declarative description of a network, usually loaded from YAML or JSON files, for example:
+//
+//	seed: 1
+//	nodes:
+//	  - {name: sender, type: endpoint}
+//	  - name: link
+//	    type: channel
+//	    options:
+//	      - {option: delay, model: fixed, delay: 10ms}
+//	      - {option: loss, model: random, possibility: 0.01}
+//	  - {name: limit, type: restrict, options: [{option: pps, limit: 1000, queue: 100}]}
+//	  - {name: receiver, type: endpoint}
+//	groups:
+//	  - {name: wan, in: limit, out: link}
+//	chains:
+//	  - [limit, link]
+//	  - [sender, wan, receiver]
+//
+// nodes are created by constructors registered with the type, in the order described, see RegisterNode,
+// and options are created by constructors registered with the option, see RegisterOption
+// each element of chains is the name of a node or a group, connected in order like Builder
+type Topology struct {
+	// Seed of random numbers shared by all models, so that runs are reproducible
+	Seed int64 `yaml:"seed"`
+	// Nodes of the network
+	Nodes []NodeSpec `yaml:"nodes"`
+	// Groups of nodes, see Builder.GroupWithName
+	Groups []GroupSpec `yaml:"groups"`
+	// Chains of names of nodes or groups
+	Chains [][]string `yaml:"chains"`
+}
+
+// NodeSpec describes a node in topology files
+type NodeSpec struct {
+	// Name of the node, must be unique
+	Name string `yaml:"name"`
+	// Type of the node registered, such as "channel" or "restrict"
+	Type string `yaml:"type"`
+	// Options of the node, each with the name of the option registered in "option", such as "delay" or "pps"
+	Options []Params `yaml:"options"`
+}
+
+// GroupSpec describes a group in topology files
+type GroupSpec struct {
+	Name string `yaml:"name"`
+	In   string `yaml:"in"`
+	Out  string `yaml:"out"`
+}
+
+// ReadTopology read the topology in YAML or JSON from the given reader
+func ReadTopology(reader io.Reader) (*Topology, error) {
+	decoder := yaml.NewDecoder(reader)
+	decoder.KnownFields(true)
+	t := &Topology{}
+	if err := decoder.Decode(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// LoadTopology load the topology file in YAML or JSON, and build the network described
+// return the built network, and a map from name to nodes, same to Builder.Build
+func LoadTopology(file string) (*Network, map[string]base.Node, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	t, err := ReadTopology(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return t.Build()
+}
+
+// Build the network described, same to Builder.Build
+func (t *Topology) Build() (*Network, map[string]base.Node, error) {
+	builder := NewBuilder()
+	if err := t.Describe(builder); err != nil {
+		return nil, nil, err
+	}
+	return builder.Build()
+}
+
+// Describe the topology with the given builder, so that more can be described in code before built
+// return all errors found in the topology, in which case nothing is described
+func (t *Topology) Describe(builder Builder) error {
+	var errs Errors
+	random := rand.New(rand.NewSource(t.Seed))
+	nodes := make([]base.Node, len(t.Nodes))
+	names := map[string]bool{}
+	for i, spec := range t.Nodes {
+		if spec.Name == "" {
+			errs = append(errs, fmt.Errorf("node %d: name cannot be empty string", i))
+			continue
+		}
+		if names[spec.Name] {
+			errs = append(errs, fmt.Errorf("node %s: duplicated name", spec.Name))
+			continue
+		}
+		names[spec.Name] = true
+		n, err := spec.create(random)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("node %s: %w", spec.Name, err))
+			continue
+		}
+		nodes[i] = n
+	}
+	for _, spec := range t.Groups {
+		if spec.Name == "" || names[spec.Name] {
+			errs = append(errs, fmt.Errorf("group %q: name must be unique and not empty", spec.Name))
+			continue
+		}
+		for _, name := range []string{spec.In, spec.Out} {
+			if !names[name] {
+				errs = append(errs, fmt.Errorf("group %s: no node with name %q", spec.Name, name))
+			}
+		}
+		names[spec.Name] = true
+	}
+	for i, chain := range t.Chains {
+		for _, name := range chain {
+			if !names[name] {
+				errs = append(errs, fmt.Errorf("chain %d: no node or group with name %q", i, name))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	for i, spec := range t.Nodes {
+		builder.Chain().NodeWithName(spec.Name, nodes[i])
+	}
+	groups := map[string]bool{}
+	for _, spec := range t.Groups {
+		builder.Chain().GroupWithName(spec.Name, spec.In, spec.Out)
+		groups[spec.Name] = true
+	}
+	for _, chain := range t.Chains {
+		builder.Chain()
+		for _, name := range chain {
+			if groups[name] {
+				builder.GroupOfName(name)
+			} else {
+				builder.NodeOfName(name)
+			}
+		}
+	}
+	builder.Chain()
+	return nil
+}
+
+// create the node described, panics raised by constructors are returned as errors
+func (spec *NodeSpec) create(random *rand.Rand) (result base.Node, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	constructor, ok := lookupNode(spec.Type)
+	if !ok {
+		return nil, fmt.Errorf("unknown node type %q", spec.Type)
+	}
+	options := make([]node.Option, 0, len(spec.Options))
+	for _, params := range spec.Options {
+		name, err := params.String("option")
+		if err != nil {
+			return nil, err
+		}
+		option, ok := lookupOption(name)
+		if !ok {
+			return nil, errors.New("unknown option " + name)
+		}
+		o, err := option(params, random)
+		if err != nil {
+			return nil, fmt.Errorf("option %s: %w", name, err)
+		}
+		options = append(options, o)
+	}
+	return constructor(options...), nil
+}
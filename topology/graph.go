@@ -0,0 +1,254 @@
+package topology
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Graph is a real-world topology read from files, such as GML of Internet Topology Zoo, or edge lists of CAIDA
+type Graph struct {
+	Nodes []GraphNode
+	Edges []GraphEdge
+}
+
+// GraphNode is a node of the graph, such as a PoP of a carrier or an AS
+type GraphNode struct {
+	// ID of the node in the file
+	ID string
+	// Label of the node, the ID if not labeled
+	Label string
+	// Latitude in degrees, valid only if Located
+	Latitude float64
+	// Longitude in degrees, valid only if Located
+	Longitude float64
+	// Located whether coordinates of the node are known
+	Located bool
+}
+
+// GraphEdge is an undirected edge of the graph
+type GraphEdge struct {
+	// Source is the index of a node in Nodes
+	Source int
+	// Target is the index of the other node in Nodes
+	Target int
+	// Capacity in bits per second, not positive if unknown
+	Capacity float64
+}
+
+// LoadGraph read the graph from the file, as GML if the extension is ".gml", otherwise as an edge list
+func LoadGraph(file string) (*Graph, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(file), ".gml") {
+		return ReadGML(f)
+	}
+	return ReadEdgeList(f)
+}
+
+// ReadEdgeList read the graph from an edge list, such as AS relationships of CAIDA
+// each line is an edge of two node IDs separated by '|' or spaces, other fields are ignored, lines starting with '#' are comments
+func ReadEdgeList(reader io.Reader) (*Graph, error) {
+	g := &Graph{}
+	indexes := map[string]int{}
+	index := func(id string) int {
+		if i, ok := indexes[id]; ok {
+			return i
+		}
+		indexes[id] = len(g.Nodes)
+		g.Nodes = append(g.Nodes, GraphNode{ID: id, Label: id})
+		return indexes[id]
+	}
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(text, func(r rune) bool {
+			return r == '|' || r == ' ' || r == '\t' || r == ','
+		})
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: edge must have two nodes", line)
+		}
+		g.Edges = append(g.Edges, GraphEdge{Source: index(fields[0]), Target: index(fields[1])})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// gmlPair is a key value pair of GML, the value is either a string of the raw token, or a list of pairs
+type gmlPair struct {
+	key   string
+	value interface{}
+}
+
+// ReadGML read the graph from GML, such as files of Internet Topology Zoo
+// nodes are labeled by "label", located by "Latitude" and "Longitude", capacity of edges is "LinkSpeedRaw" of Topology Zoo,
+// or "capacity" or "bandwidth" in bits per second, keys are case-insensitive and unknown keys are ignored
+func ReadGML(reader io.Reader) (*Graph, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := tokenize(string(data))
+	if err != nil {
+		return nil, err
+	}
+	pairs, rest, err := parseGML(tokens, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("gml: unexpected ]")
+	}
+	var graph []gmlPair
+	for _, pair := range pairs {
+		if list, ok := pair.value.([]gmlPair); ok && strings.EqualFold(pair.key, "graph") {
+			graph = list
+			break
+		}
+	}
+	if graph == nil {
+		return nil, errors.New("gml: no graph")
+	}
+	g := &Graph{}
+	indexes := map[string]int{}
+	for _, pair := range graph {
+		list, ok := pair.value.([]gmlPair)
+		if !ok || !strings.EqualFold(pair.key, "node") {
+			continue
+		}
+		n := GraphNode{ID: gmlValue(list, "id")}
+		if n.ID == "" {
+			return nil, fmt.Errorf("gml: node %d: no id", len(g.Nodes))
+		}
+		if _, ok := indexes[n.ID]; ok {
+			return nil, fmt.Errorf("gml: node %s: duplicated id", n.ID)
+		}
+		n.Label = gmlValue(list, "label")
+		if n.Label == "" {
+			n.Label = n.ID
+		}
+		latitude, err1 := strconv.ParseFloat(gmlValue(list, "latitude"), 64)
+		longitude, err2 := strconv.ParseFloat(gmlValue(list, "longitude"), 64)
+		if err1 == nil && err2 == nil {
+			n.Latitude, n.Longitude, n.Located = latitude, longitude, true
+		}
+		indexes[n.ID] = len(g.Nodes)
+		g.Nodes = append(g.Nodes, n)
+	}
+	for _, pair := range graph {
+		list, ok := pair.value.([]gmlPair)
+		if !ok || !strings.EqualFold(pair.key, "edge") {
+			continue
+		}
+		source, ok1 := indexes[gmlValue(list, "source")]
+		target, ok2 := indexes[gmlValue(list, "target")]
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("gml: edge %d: no node with id %q or %q", len(g.Edges), gmlValue(list, "source"), gmlValue(list, "target"))
+		}
+		e := GraphEdge{Source: source, Target: target}
+		for _, key := range []string{"LinkSpeedRaw", "capacity", "bandwidth"} {
+			if capacity, err := strconv.ParseFloat(gmlValue(list, key), 64); err == nil {
+				e.Capacity = capacity
+				break
+			}
+		}
+		g.Edges = append(g.Edges, e)
+	}
+	return g, nil
+}
+
+// tokenize GML into keys, values and brackets, strings are kept quoted, lines starting with '#' are comments
+func tokenize(data string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '#' && (i == 0 || data[i-1] == '\n'):
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case c == '[' || c == ']':
+			tokens = append(tokens, data[i:i+1])
+			i++
+		case c == '"':
+			end := strings.IndexByte(data[i+1:], '"')
+			if end < 0 {
+				return nil, errors.New("gml: unterminated string")
+			}
+			tokens = append(tokens, data[i:i+end+2])
+			i += end + 2
+		default:
+			start := i
+			for i < len(data) && !strings.ContainsRune(" \t\r\n[]\"", rune(data[i])) {
+				i++
+			}
+			tokens = append(tokens, data[start:i])
+		}
+	}
+	return tokens, nil
+}
+
+// parseGML parse key value pairs until the end of tokens, or the ']' closing the list if nested
+// return the pairs and tokens after the list
+func parseGML(tokens []string, nested bool) ([]gmlPair, []string, error) {
+	var pairs []gmlPair
+	for len(tokens) > 0 {
+		key := tokens[0]
+		if key == "]" {
+			if !nested {
+				return pairs, tokens, nil
+			}
+			return pairs, tokens[1:], nil
+		}
+		if key == "[" || strings.HasPrefix(key, `"`) {
+			return nil, nil, fmt.Errorf("gml: unexpected %s", key)
+		}
+		if len(tokens) < 2 {
+			return nil, nil, fmt.Errorf("gml: no value of %s", key)
+		}
+		if tokens[1] == "[" {
+			list, rest, err := parseGML(tokens[2:], true)
+			if err != nil {
+				return nil, nil, err
+			}
+			pairs = append(pairs, gmlPair{key: key, value: list})
+			tokens = rest
+			continue
+		}
+		if tokens[1] == "]" {
+			return nil, nil, fmt.Errorf("gml: no value of %s", key)
+		}
+		pairs = append(pairs, gmlPair{key: key, value: tokens[1]})
+		tokens = tokens[2:]
+	}
+	if nested {
+		return nil, nil, errors.New("gml: unterminated list")
+	}
+	return pairs, nil, nil
+}
+
+// gmlValue return the value of the first pair with the key in the list, unquoted, empty if none or a list
+func gmlValue(list []gmlPair, key string) string {
+	for _, pair := range list {
+		if value, ok := pair.value.(string); ok && strings.EqualFold(pair.key, key) {
+			return strings.Trim(value, `"`)
+		}
+	}
+	return ""
+}
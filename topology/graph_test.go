@@ -0,0 +1,78 @@
+package topology
+
+import (
+	"github.com/bytedance/ns-x/v2"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+const gml = `# exported from Internet Topology Zoo
+Creator "test"
+graph [
+  DirectedType 0
+  node [
+    id 0
+    label "West"
+    Latitude 0
+    Longitude 0
+  ]
+  node [ id 1 label "East" Latitude 0.0 Longitude 1.0 ]
+  node [ id 2 label "West" Internal [ flag 1 ] ]
+  edge [ source 0 target 1 LinkSpeedRaw 8000000.0 LinkLabel "8 Mbps" ]
+  edge [ source 1 target 2 ]
+  edge [ source 2 target 2 ]
+]
+`
+
+func TestGML(t *testing.T) {
+	graph, err := ReadGML(strings.NewReader(gml))
+	assert.NoError(t, err)
+	assert.Equal(t, []GraphNode{
+		{ID: "0", Label: "West", Located: true},
+		{ID: "1", Label: "East", Longitude: 1, Located: true},
+		{ID: "2", Label: "West"},
+	}, graph.Nodes)
+	assert.Equal(t, []GraphEdge{{Source: 0, Target: 1, Capacity: 8e6}, {Source: 1, Target: 2}, {Source: 2, Target: 2}}, graph.Edges)
+	// a degree of the equator
+	assert.InDelta(t, 111195, distance(graph.Nodes[0], graph.Nodes[1]), 1)
+
+	builder := ns_x.NewBuilder()
+	topology := Import(builder, graph, ImportSpec{Default: spec})
+	assert.Equal(t, []string{"West", "East", "West#2"}, topology.Routers)
+	assert.Equal(t, []string{"West/host", "East/host", "West#2/host"}, topology.Hosts)
+	assert.Equal(t, 5, len(topology.Links))
+	assert.Equal(t, []string{"bps: 1e+06"}, topology.Links[3].Forward.Restrict.Describe())
+	assert.Nil(t, topology.Links[4].Forward.Restrict)
+	propagation := time.Duration(distance(graph.Nodes[0], graph.Nodes[1]) / 2e8 * float64(time.Second))
+	assert.Equal(t, []time.Duration{hops(2) + propagation, hops(3)}, deliver(t, builder, topology,
+		[2]string{"West/host", "East/host"},
+		[2]string{"West#2/host", "East/host"},
+	))
+
+	for _, data := range []string{"graph [ node [ id 0 ]", "graph [ edge [ source 0 target 1 ] ]", "graph [ node [ id ] ]", "graph ] ]", "creator"} {
+		_, err = ReadGML(strings.NewReader(data))
+		assert.Error(t, err, data)
+	}
+}
+
+func TestEdgeList(t *testing.T) {
+	graph, err := ReadEdgeList(strings.NewReader("# <provider-as>|<customer-as>|-1\n1|2|-1\n\n2|3|0\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []GraphNode{{ID: "1", Label: "1"}, {ID: "2", Label: "2"}, {ID: "3", Label: "3"}}, graph.Nodes)
+	assert.Equal(t, []GraphEdge{{Source: 0, Target: 1}, {Source: 1, Target: 2}}, graph.Edges)
+	builder := ns_x.NewBuilder()
+	topology := Import(builder, graph, ImportSpec{Default: spec})
+	assert.Equal(t, []time.Duration{hops(4)}, deliver(t, builder, topology, [2]string{"1/host", "3/host"}))
+	// links are not delayed by default, or delayed by MinDelay between routers not located
+	builder = ns_x.NewBuilder()
+	topology = Import(builder, graph, ImportSpec{})
+	assert.Equal(t, []time.Duration{0}, deliver(t, builder, topology, [2]string{"1/host", "3/host"}))
+	builder = ns_x.NewBuilder()
+	topology = Import(builder, graph, ImportSpec{MinDelay: 10 * time.Millisecond})
+	assert.Equal(t, []time.Duration{hops(2)}, deliver(t, builder, topology, [2]string{"1/host", "3/host"}))
+
+	_, err = ReadEdgeList(strings.NewReader("1 2\n3\n"))
+	assert.EqualError(t, err, "line 2: edge must have two nodes")
+}
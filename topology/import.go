@@ -0,0 +1,105 @@
+package topology
+
+import (
+	"github.com/bytedance/ns-x/v2"
+	"github.com/bytedance/ns-x/v2/math"
+	"github.com/bytedance/ns-x/v2/node"
+	gomath "math"
+	"time"
+)
+
+// earthRadius in meters, used to compute great-circle distances
+const earthRadius = 6371e3
+
+// ImportSpec describes how to map attributes of the graph to links
+type ImportSpec struct {
+	// Default create the spec of each link, including links between hosts and routers, attributes of the graph override it
+	// if known, links are not limited, delayed or lost by default if nil
+	Default LinkFactory
+	// Speed of signals in meters per second, delay of links between located nodes is the great-circle distance divided by it
+	// 2e8 if not positive, which is the speed of light in fibre
+	Speed float64
+	// MinDelay is the lower bound of delay computed from coordinates, such as for nodes in the same city,
+	// and the delay of links between routers not located if positive and the default spec has no delay
+	MinDelay time.Duration
+	// QueueBytes limits the size of packets queued by links limited by capacity, unlimited if not positive
+	QueueBytes int64
+}
+
+// Import generate the graph as routers named after labels of nodes, each with a host "{label}/host" linked by the default spec
+// labels are made unique by the suffix "#{id}" if duplicated, and self loops are ignored
+// edges are duplex links, limited in bytes per second by the capacity, and delayed by the distance of located nodes
+func Import(builder ns_x.Builder, graph *Graph, spec ImportSpec) *Topology {
+	if spec.Speed <= 0 {
+		spec.Speed = 2e8
+	}
+	if spec.Default == nil {
+		spec.Default = Shared(ns_x.LinkSpec{})
+	}
+	g := newGenerator(builder, spec.Default)
+	names := make([]string, len(graph.Nodes))
+	used := map[string]bool{}
+	for i, n := range graph.Nodes {
+		name := n.Label
+		if used[name] {
+			name += "#" + n.ID
+		}
+		used[name] = true
+		names[i] = name
+		g.router(name)
+	}
+	for _, name := range names {
+		g.host(name+"/host", name)
+	}
+	for _, e := range graph.Edges {
+		if e.Source == e.Target {
+			continue
+		}
+		link := spec.Default()
+		if link.Reverse != nil {
+			reverse := *link.Reverse
+			link.Reverse = &reverse
+		}
+		apply := func(d *ns_x.LinkDirection) {
+			if e.Capacity > 0 {
+				d.BPS = e.Capacity / 8
+				d.QueueBytes = spec.QueueBytes
+			}
+			source, target := graph.Nodes[e.Source], graph.Nodes[e.Target]
+			if source.Located && target.Located {
+				delay := time.Duration(distance(source, target) / spec.Speed * float64(time.Second))
+				if delay < spec.MinDelay {
+					delay = spec.MinDelay
+				}
+				d.Delay = fixed(delay)
+			} else if d.Delay == nil && spec.MinDelay > 0 {
+				d.Delay = fixed(spec.MinDelay)
+			}
+		}
+		apply(&link.LinkDirection)
+		if link.Reverse != nil {
+			apply(link.Reverse)
+		}
+		g.link(names[e.Source], names[e.Target], link)
+	}
+	return g.finish()
+}
+
+// distance in meters along the great circle between located nodes, by the haversine formula
+func distance(a, b GraphNode) float64 {
+	radians := func(degrees float64) float64 {
+		return degrees * gomath.Pi / 180
+	}
+	dLatitude := radians(b.Latitude - a.Latitude)
+	dLongitude := radians(b.Longitude - a.Longitude)
+	h := gomath.Pow(gomath.Sin(dLatitude/2), 2) +
+		gomath.Cos(radians(a.Latitude))*gomath.Cos(radians(b.Latitude))*gomath.Pow(gomath.Sin(dLongitude/2), 2)
+	return 2 * earthRadius * gomath.Asin(gomath.Sqrt(h))
+}
+
+// fixed create fixed delays of the given duration for each direction
+func fixed(delay time.Duration) func() node.DescribedDelay {
+	return func() node.DescribedDelay {
+		return math.NewFixedDelayModel(delay)
+	}
+}
@@ -0,0 +1,221 @@
+package topology
+
+import (
+	"github.com/bytedance/ns-x/v2"
+	"math"
+	"math/rand"
+	"strconv"
+)
+
+// Star generate a router "hub" with hosts "host0".."host{n-1}" linked to it
+func Star(builder ns_x.Builder, hosts int, spec LinkFactory) *Topology {
+	g := newGenerator(builder, spec)
+	g.router("hub")
+	for i := 0; i < hosts; i++ {
+		g.host("host"+strconv.Itoa(i), "hub")
+	}
+	return g.finish()
+}
+
+// routers describe routers "router0".."router{n-1}", each with a host "host{i}" linked to it
+func (g *generator) routers(count int) {
+	for i := 0; i < count; i++ {
+		g.router(routerName(i))
+	}
+	for i := 0; i < count; i++ {
+		g.host("host"+strconv.Itoa(i), routerName(i))
+	}
+}
+
+func routerName(i int) string {
+	return "router" + strconv.Itoa(i)
+}
+
+// Line generate routers "router0".."router{n-1}" linked one by one, each with a host "host{i}"
+func Line(builder ns_x.Builder, routers int, spec LinkFactory) *Topology {
+	g := newGenerator(builder, spec)
+	g.routers(routers)
+	for i := 1; i < routers; i++ {
+		g.link(routerName(i-1), routerName(i), spec())
+	}
+	return g.finish()
+}
+
+// Ring same to Line, but the last router is linked to the first one as well
+func Ring(builder ns_x.Builder, routers int, spec LinkFactory) *Topology {
+	g := newGenerator(builder, spec)
+	g.routers(routers)
+	for i := 1; i < routers; i++ {
+		g.link(routerName(i-1), routerName(i), spec())
+	}
+	if routers > 2 {
+		g.link(routerName(routers-1), routerName(0), spec())
+	}
+	return g.finish()
+}
+
+// Mesh generate routers "router0".."router{n-1}" linked to each other, each with a host "host{i}"
+func Mesh(builder ns_x.Builder, routers int, spec LinkFactory) *Topology {
+	g := newGenerator(builder, spec)
+	g.routers(routers)
+	for i := 0; i < routers; i++ {
+		for j := i + 1; j < routers; j++ {
+			g.link(routerName(i), routerName(j), spec())
+		}
+	}
+	return g.finish()
+}
+
+// Dumbbell generate routers "left" and "right" linked by the bottleneck, with senders "sender0".."sender{n-1}" linked to
+// the left one, and receivers "receiver0".."receiver{n-1}" linked to the right one
+func Dumbbell(builder ns_x.Builder, senders int, spec, bottleneck LinkFactory) *Topology {
+	g := newGenerator(builder, spec)
+	g.router("left")
+	g.router("right")
+	g.link("left", "right", bottleneck())
+	for i := 0; i < senders; i++ {
+		g.host("sender"+strconv.Itoa(i), "left")
+	}
+	for i := 0; i < senders; i++ {
+		g.host("receiver"+strconv.Itoa(i), "right")
+	}
+	return g.finish()
+}
+
+// FatTree generate a k-ary fat-tree, k must be even: (k/2)^2 core routers "core{i}", k pods each with k/2 aggregation
+// routers "pod{p}/agg{i}" and k/2 edge routers "pod{p}/edge{i}", and k/2 hosts "pod{p}/edge{i}/host{j}" for each edge router
+// the i-th aggregation router of each pod is linked to core routers from i*k/2 to (i+1)*k/2-1
+func FatTree(builder ns_x.Builder, k int, spec LinkFactory) *Topology {
+	if k <= 0 || k%2 != 0 {
+		panic("k of fat-tree must be positive and even")
+	}
+	half := k / 2
+	g := newGenerator(builder, spec)
+	for i := 0; i < half*half; i++ {
+		g.router("core" + strconv.Itoa(i))
+	}
+	for p := 0; p < k; p++ {
+		pod := "pod" + strconv.Itoa(p)
+		for i := 0; i < half; i++ {
+			agg := pod + "/agg" + strconv.Itoa(i)
+			g.router(agg)
+			for j := 0; j < half; j++ {
+				g.link(agg, "core"+strconv.Itoa(i*half+j), spec())
+			}
+		}
+		for i := 0; i < half; i++ {
+			edge := pod + "/edge" + strconv.Itoa(i)
+			g.router(edge)
+			for j := 0; j < half; j++ {
+				g.link(edge, pod+"/agg"+strconv.Itoa(j), spec())
+			}
+		}
+		for i := 0; i < half; i++ {
+			edge := pod + "/edge" + strconv.Itoa(i)
+			for j := 0; j < half; j++ {
+				g.host(edge+"/host"+strconv.Itoa(j), edge)
+			}
+		}
+	}
+	return g.finish()
+}
+
+// LeafSpine generate spine routers "spine{i}" and leaf routers "leaf{i}" linked to every spine router,
+// with hosts "leaf{i}/host{j}" linked to each leaf router
+func LeafSpine(builder ns_x.Builder, leaves, spines, hosts int, spec LinkFactory) *Topology {
+	g := newGenerator(builder, spec)
+	for i := 0; i < spines; i++ {
+		g.router("spine" + strconv.Itoa(i))
+	}
+	for i := 0; i < leaves; i++ {
+		leaf := "leaf" + strconv.Itoa(i)
+		g.router(leaf)
+		for j := 0; j < spines; j++ {
+			g.link(leaf, "spine"+strconv.Itoa(j), spec())
+		}
+	}
+	for i := 0; i < leaves; i++ {
+		leaf := "leaf" + strconv.Itoa(i)
+		for j := 0; j < hosts; j++ {
+			g.host(leaf+"/host"+strconv.Itoa(j), leaf)
+		}
+	}
+	return g.finish()
+}
+
+// Waxman generate a random Waxman graph of routers "router0".."router{n-1}", each with a host "host{i}"
+// routers are placed in a unit square uniformly, and each pair is linked with possibility beta*exp(-d/(alpha*L)),
+// where d is the distance of the pair and L is the max distance, components are joined by linking their first routers to "router0"
+func Waxman(builder ns_x.Builder, routers int, alpha, beta float64, spec LinkFactory, random *rand.Rand) *Topology {
+	if alpha <= 0 || beta <= 0 || beta > 1 || random == nil {
+		panic("invalid argument")
+	}
+	g := newGenerator(builder, spec)
+	g.routers(routers)
+	x, y := make([]float64, routers), make([]float64, routers)
+	for i := range x {
+		x[i], y[i] = random.Float64(), random.Float64()
+	}
+	parents := make([]int, routers)
+	for i := range parents {
+		parents[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parents[i] != i {
+			parents[i] = find(parents[i])
+		}
+		return parents[i]
+	}
+	for i := 0; i < routers; i++ {
+		for j := i + 1; j < routers; j++ {
+			d := math.Hypot(x[i]-x[j], y[i]-y[j])
+			if random.Float64() < beta*math.Exp(-d/(alpha*math.Sqrt2)) {
+				g.link(routerName(i), routerName(j), spec())
+				parents[find(i)] = find(j)
+			}
+		}
+	}
+	for i := 1; i < routers; i++ {
+		// routers are visited in order, so that i is the first router of its component
+		if find(i) != find(0) {
+			g.link(routerName(0), routerName(i), spec())
+			parents[find(i)] = find(0)
+		}
+	}
+	return g.finish()
+}
+
+// BarabasiAlbert generate a random scale-free graph of routers "router0".."router{n-1}" by preferential attachment,
+// each with a host "host{i}", the first m+1 routers are linked to each other, and then each router is linked to m
+// distinct routers before it, with possibility in proportion to their degrees
+func BarabasiAlbert(builder ns_x.Builder, routers, m int, spec LinkFactory, random *rand.Rand) *Topology {
+	if m <= 0 || routers <= m || random == nil {
+		panic("invalid argument")
+	}
+	g := newGenerator(builder, spec)
+	g.routers(routers)
+	var ends []int // each router appears once for each link, so that it's chosen in proportion to its degree
+	for i := 0; i <= m; i++ {
+		for j := i + 1; j <= m; j++ {
+			g.link(routerName(i), routerName(j), spec())
+			ends = append(ends, i, j)
+		}
+	}
+	for i := m + 1; i < routers; i++ {
+		chosen := map[int]bool{}
+		var targets []int
+		for len(targets) < m {
+			target := ends[random.Intn(len(ends))]
+			if !chosen[target] {
+				chosen[target] = true
+				targets = append(targets, target)
+			}
+		}
+		for _, target := range targets {
+			g.link(routerName(target), routerName(i), spec())
+			ends = append(ends, target, i)
+		}
+	}
+	return g.finish()
+}
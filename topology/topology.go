@@ -0,0 +1,162 @@
+// Package topology generates common shapes of networks on the Builder, such as star, ring, dumbbell and fat-tree
+// each shape is made of routers, which are ScatterNode routing packets along shortest paths in hops, and hosts,
+// which are EndpointNode linked to a router, routers and hosts are connected by duplex links described by Builder.Link
+// packets to be routed must be *base.SimulatePacket with the target host as Target
+// each link generated is described by a spec created by the given LinkFactory, links may have no delay,
+// since cycles through routers are not reported by the validation of the builder
+// names generated are fixed like "host0", generate shapes by Instantiate to have more than one in a builder
+package topology
+
+import (
+	"github.com/bytedance/ns-x/v2"
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/node"
+)
+
+// Topology is a shape generated on the builder
+type Topology struct {
+	// Hosts are names of endpoints, in the order generated
+	Hosts []string
+	// Routers are names of routers, in the order generated
+	Routers []string
+	// Links of the shape, in the order generated
+	Links []*ns_x.Link
+}
+
+// LinkFactory create the spec of a link each time called, so that links generated can differ, such as in random capacities
+type LinkFactory func() ns_x.LinkSpec
+
+// Shared return a LinkFactory always returning the given spec, models are still created by the spec for each direction
+func Shared(spec ns_x.LinkSpec) LinkFactory {
+	return func() ns_x.LinkSpec {
+		return spec
+	}
+}
+
+// Instantiate generate the shape by generate in an instance of the given name, see Builder.Instantiate, so that names
+// never clash with other shapes in the builder, names in the topology returned are prefixed like "site1/host0"
+func Instantiate(builder ns_x.Builder, name string, generate func(builder ns_x.Builder) *Topology) *Topology {
+	topology := &Topology{}
+	builder.Instantiate(name, func(scope ns_x.Scope, params ns_x.Params) error {
+		topology = generate(scope)
+		return nil
+	}, nil)
+	prefix := name + "/"
+	for i, host := range topology.Hosts {
+		topology.Hosts[i] = prefix + host
+	}
+	for i, router := range topology.Routers {
+		topology.Routers[i] = prefix + router
+	}
+	return topology
+}
+
+// hop is a link from a node to the neighbour
+type hop struct {
+	to    string
+	entry base.Node // the first node of the direction to the neighbour
+}
+
+// generator describes routers, hosts and links on the builder, and fills routing tables of routers at last
+type generator struct {
+	builder   ns_x.Builder
+	spec      LinkFactory
+	topology  *Topology
+	nodes     map[string]base.Node
+	hosts     map[string]bool
+	neighbors map[string][]hop
+	tables    map[string]map[base.Node]base.Node // next hop to each host of each router
+}
+
+func newGenerator(builder ns_x.Builder, spec LinkFactory) *generator {
+	return &generator{
+		builder:   builder,
+		spec:      spec,
+		topology:  &Topology{},
+		nodes:     map[string]base.Node{},
+		hosts:     map[string]bool{},
+		neighbors: map[string][]hop{},
+		tables:    map[string]map[base.Node]base.Node{},
+	}
+}
+
+// router describe a router with the given name
+func (g *generator) router(name string) {
+	table := map[base.Node]base.Node{}
+	n := node.NewScatterNode(node.WithRouteSelector(func(packet base.Packet, nodes []base.Node) base.Node {
+		p, ok := packet.(*base.SimulatePacket)
+		if !ok {
+			panic("packets routed by generated topologies must be *base.SimulatePacket")
+		}
+		next, ok := table[p.Target]
+		if !ok {
+			panic("no route to the target")
+		}
+		return next
+	}))
+	g.tables[name] = table
+	g.nodes[name] = n
+	g.topology.Routers = append(g.topology.Routers, name)
+	g.builder.Chain().NodeWithName(name, n).Chain()
+}
+
+// host describe a host with the given name, linked to the given router
+func (g *generator) host(name, router string) {
+	n := node.NewEndpointNode()
+	g.nodes[name] = n
+	g.hosts[name] = true
+	g.topology.Hosts = append(g.topology.Hosts, name)
+	g.builder.Chain().NodeWithName(name, n).Chain()
+	g.link(name, router, g.spec())
+}
+
+// link describe a duplex link between the given nodes
+func (g *generator) link(a, b string, spec ns_x.LinkSpec) {
+	link := g.builder.Link(a, b, spec)
+	g.topology.Links = append(g.topology.Links, link)
+	g.neighbors[a] = append(g.neighbors[a], hop{to: b, entry: entry(link.Forward)})
+	g.neighbors[b] = append(g.neighbors[b], hop{to: a, entry: entry(link.Backward)})
+}
+
+// entry return the first node of the direction
+func entry(h *ns_x.LinkHandle) base.Node {
+	if h.Restrict != nil {
+		return h.Restrict
+	}
+	return h.Channel
+}
+
+// finish fill routing tables by breadth first search from each host, packets never pass through other hosts
+// then return the topology generated
+func (g *generator) finish() *Topology {
+	for _, host := range g.topology.Hosts {
+		distance := map[string]int{host: 0}
+		queue := []string{host}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			if g.hosts[current] && current != host {
+				continue
+			}
+			for _, h := range g.neighbors[current] {
+				if _, ok := distance[h.to]; !ok {
+					distance[h.to] = distance[current] + 1
+					queue = append(queue, h.to)
+				}
+			}
+		}
+		for _, router := range g.topology.Routers {
+			d, ok := distance[router]
+			if !ok {
+				continue
+			}
+			for _, h := range g.neighbors[router] {
+				if next, ok := distance[h.to]; ok && next == d-1 && (!g.hosts[h.to] || h.to == host) {
+					g.tables[router][g.nodes[host]] = h.entry
+					break
+				}
+			}
+		}
+	}
+	return g.topology
+}
@@ -0,0 +1,173 @@
+package topology
+
+import (
+	"github.com/bytedance/ns-x/v2"
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/math"
+	"github.com/bytedance/ns-x/v2/node"
+	"github.com/bytedance/ns-x/v2/tick"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+var spec = Shared(ns_x.LinkSpec{LinkDirection: ns_x.LinkDirection{Delay: fixed(10 * time.Millisecond)}})
+
+// deliver build the network, send a packet for each pair of hosts, and return the time taken by each packet
+func deliver(t *testing.T, builder ns_x.Builder, topology *Topology, pairs ...[2]string) []time.Duration {
+	network, nodes, err := builder.Build()
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	now := time.Unix(0, 0)
+	result := make([]time.Duration, len(pairs))
+	index := map[base.Packet]int{}
+	for _, host := range topology.Hosts {
+		nodes[host].(*node.EndpointNode).Receive(func(packet base.Packet, t time.Time) []base.Event {
+			result[index[packet]] = t.Sub(now)
+			return nil
+		})
+	}
+	var events []base.Event
+	for i, pair := range pairs {
+		packet := &base.SimulatePacket{Data: base.RawPacket{}, Source: nodes[pair[0]], Target: nodes[pair[1]]}
+		index[packet] = i
+		result[i] = -1
+		events = append(events, nodes[pair[0]].(*node.EndpointNode).Send(packet, now))
+	}
+	assert.NoError(t, network.Run(events, tick.NewStepClock(now, time.Millisecond), time.Minute, ns_x.WithVirtualTime()))
+	_, err = network.Wait()
+	assert.NoError(t, err)
+	return result
+}
+
+func hops(count int) time.Duration {
+	return time.Duration(count) * 10 * time.Millisecond
+}
+
+func TestStar(t *testing.T) {
+	builder := ns_x.NewBuilder()
+	topology := Star(builder, 3, spec)
+	assert.Equal(t, []string{"host0", "host1", "host2"}, topology.Hosts)
+	assert.Equal(t, []string{"hub"}, topology.Routers)
+	assert.Equal(t, 3, len(topology.Links))
+	assert.Equal(t, []time.Duration{hops(2), hops(2)}, deliver(t, builder, topology, [2]string{"host0", "host2"}, [2]string{"host2", "host1"}))
+}
+
+func TestLinkFactory(t *testing.T) {
+	builder := ns_x.NewBuilder()
+	links, losses := 0, 0
+	topology := Star(builder, 3, func() ns_x.LinkSpec {
+		links++
+		loss := func() node.DescribedLoss {
+			losses++
+			return math.NewRandomLossModel(0.1, rand.New(rand.NewSource(int64(losses))))
+		}
+		return ns_x.LinkSpec{LinkDirection: ns_x.LinkDirection{Delay: fixed(10 * time.Millisecond), Loss: loss}}
+	})
+	assert.Equal(t, 3, len(topology.Links))
+	assert.Equal(t, 3, links)
+	// models are created for each direction
+	assert.Equal(t, 6, losses)
+}
+
+func TestUndelayedLinks(t *testing.T) {
+	// links between routers without delay are fine, since routes never loop
+	builder := ns_x.NewBuilder()
+	topology := Line(builder, 3, Shared(ns_x.LinkSpec{LinkDirection: ns_x.LinkDirection{BPS: 1e6}}))
+	assert.Equal(t, []time.Duration{0, 0}, deliver(t, builder, topology, [2]string{"host0", "host2"}, [2]string{"host2", "host1"}))
+}
+
+func TestLineAndRing(t *testing.T) {
+	builder := ns_x.NewBuilder()
+	topology := Line(builder, 6, spec)
+	assert.Equal(t, 11, len(topology.Links))
+	assert.Equal(t, []time.Duration{hops(7), hops(3)}, deliver(t, builder, topology, [2]string{"host0", "host5"}, [2]string{"host3", "host2"}))
+
+	builder = ns_x.NewBuilder()
+	topology = Ring(builder, 6, spec)
+	assert.Equal(t, 12, len(topology.Links))
+	assert.Equal(t, []time.Duration{hops(3), hops(5)}, deliver(t, builder, topology, [2]string{"host0", "host5"}, [2]string{"host1", "host4"}))
+}
+
+func TestMesh(t *testing.T) {
+	builder := ns_x.NewBuilder()
+	topology := Mesh(builder, 4, spec)
+	assert.Equal(t, 10, len(topology.Links))
+	assert.Equal(t, []time.Duration{hops(3), hops(3)}, deliver(t, builder, topology, [2]string{"host0", "host3"}, [2]string{"host2", "host1"}))
+}
+
+func TestDumbbell(t *testing.T) {
+	builder := ns_x.NewBuilder()
+	bottleneck := Shared(ns_x.LinkSpec{LinkDirection: ns_x.LinkDirection{Delay: fixed(50 * time.Millisecond)}})
+	topology := Dumbbell(builder, 2, spec, bottleneck)
+	assert.Equal(t, []string{"sender0", "sender1", "receiver0", "receiver1"}, topology.Hosts)
+	assert.Equal(t, "left->right", topology.Links[0].Forward.Name)
+	assert.Equal(t, []time.Duration{hops(7), hops(2)}, deliver(t, builder, topology, [2]string{"sender0", "receiver1"}, [2]string{"sender0", "sender1"}))
+}
+
+func TestFatTree(t *testing.T) {
+	builder := ns_x.NewBuilder()
+	topology := FatTree(builder, 4, spec)
+	assert.Equal(t, 16, len(topology.Hosts))
+	assert.Equal(t, 20, len(topology.Routers))
+	assert.Equal(t, 48, len(topology.Links))
+	assert.Equal(t, []time.Duration{hops(2), hops(4), hops(6)}, deliver(t, builder, topology,
+		[2]string{"pod0/edge0/host0", "pod0/edge0/host1"},
+		[2]string{"pod0/edge0/host0", "pod0/edge1/host0"},
+		[2]string{"pod0/edge0/host0", "pod3/edge1/host1"},
+	))
+	assert.Panics(t, func() { FatTree(ns_x.NewBuilder(), 3, spec) })
+}
+
+func TestLeafSpine(t *testing.T) {
+	builder := ns_x.NewBuilder()
+	topology := LeafSpine(builder, 3, 2, 2, spec)
+	assert.Equal(t, 6, len(topology.Hosts))
+	assert.Equal(t, 5, len(topology.Routers))
+	assert.Equal(t, 12, len(topology.Links))
+	assert.Equal(t, []time.Duration{hops(2), hops(4)}, deliver(t, builder, topology,
+		[2]string{"leaf0/host0", "leaf0/host1"},
+		[2]string{"leaf0/host0", "leaf2/host1"},
+	))
+}
+
+func TestRandom(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for _, generate := range []func(builder ns_x.Builder) *Topology{
+		func(builder ns_x.Builder) *Topology {
+			return Waxman(builder, 20, 0.1, 0.2, spec, random)
+		},
+		func(builder ns_x.Builder) *Topology {
+			topology := BarabasiAlbert(builder, 20, 2, spec, random)
+			assert.Equal(t, 20+3+17*2, len(topology.Links))
+			return topology
+		},
+	} {
+		builder := ns_x.NewBuilder()
+		topology := generate(builder)
+		var pairs [][2]string
+		for _, host := range topology.Hosts[1:] {
+			pairs = append(pairs, [2]string{topology.Hosts[0], host})
+		}
+		// every host is connected
+		for _, d := range deliver(t, builder, topology, pairs...) {
+			assert.True(t, d >= hops(3))
+		}
+	}
+}
+
+func TestInstantiate(t *testing.T) {
+	builder := ns_x.NewBuilder()
+	star := func(builder ns_x.Builder) *Topology {
+		return Star(builder, 3, spec)
+	}
+	a := Instantiate(builder, "a", star)
+	b := Instantiate(builder, "b", star)
+	assert.Equal(t, []string{"a/host0", "a/host1", "a/host2"}, a.Hosts)
+	assert.Equal(t, []string{"b/hub"}, b.Routers)
+	assert.Equal(t, "b/host0->b/hub", b.Links[0].Forward.Name)
+	both := &Topology{Hosts: append(a.Hosts, b.Hosts...)}
+	assert.Equal(t, []time.Duration{hops(2), hops(2)}, deliver(t, builder, both, [2]string{"a/host0", "a/host2"}, [2]string{"b/host1", "b/host0"}))
+}
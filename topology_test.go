@@ -0,0 +1,122 @@
+package ns_x
+
+import (
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/node"
+	"github.com/bytedance/ns-x/v2/tick"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const topologyYAML = `
+seed: 1
+nodes:
+  - {name: sender, type: endpoint}
+  - name: link
+    type: channel
+    options:
+      - {option: delay, model: fixed, delay: 10ms}
+      - {option: loss, model: random, possibility: 0}
+  - {name: limit, type: restrict, options: [{option: pps, limit: 1000, queue: 100}]}
+  - {name: receiver, type: endpoint}
+groups:
+  - {name: wan, in: limit, out: link}
+chains:
+  - [limit, link]
+  - [sender, wan, receiver]
+`
+
+const topologyJSON = `{
+  "nodes": [
+    {"name": "sender", "type": "endpoint"},
+    {"name": "scatter", "type": "scatter", "options": [{"option": "route", "selector": "round_robin"}]},
+    {"name": "a", "type": "channel", "options": [{"option": "delay", "model": "uniform", "average": "1ms"}]},
+    {"name": "b", "type": "counter"},
+    {"name": "receiver", "type": "endpoint"}
+  ],
+  "chains": [["sender", "scatter", "a", "receiver"], ["scatter", "b", "receiver"]]
+}`
+
+// countingNode is a user-defined node counting packets passed by
+type countingNode struct {
+	*node.GatherNode
+	count int
+}
+
+func (c *countingNode) Transfer(packet base.Packet, now time.Time) []base.Event {
+	c.count++
+	return c.GatherNode.Transfer(packet, now)
+}
+
+func TestLoadTopology(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "topology.yaml")
+	assert.NoError(t, ioutil.WriteFile(file, []byte(topologyYAML), 0644))
+	network, nodes, err := LoadTopology(file)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(network.Nodes()))
+	assert.Equal(t, []base.Node{nodes["limit"]}, nodes["sender"].GetNext())
+	assert.Equal(t, []base.Node{nodes["link"]}, nodes["limit"].GetNext())
+	assert.Equal(t, []base.Node{nodes["receiver"]}, nodes["link"].GetNext())
+	delay, ok := nodes["link"].(*node.ChannelNode).MinDelay()
+	assert.True(t, ok)
+	assert.Equal(t, 10*time.Millisecond, delay)
+
+	_, _, err = LoadTopology(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestTopologyUserDefined(t *testing.T) {
+	RegisterNode("counter", func(options ...node.Option) base.Node {
+		return &countingNode{GatherNode: node.NewGatherNode(options...)}
+	})
+	assert.Contains(t, NodeTypes(), "counter")
+	topology, err := ReadTopology(strings.NewReader(topologyJSON))
+	assert.NoError(t, err)
+	network, nodes, err := topology.Build()
+	assert.NoError(t, err)
+	received := 0
+	nodes["receiver"].(*node.EndpointNode).Receive(func(packet base.Packet, now time.Time) []base.Event {
+		received++
+		return nil
+	})
+	now := time.Unix(0, 0)
+	var events []base.Event
+	for i := 0; i < 10; i++ {
+		events = append(events, nodes["sender"].(*node.EndpointNode).Send(base.RawPacket{}, now))
+	}
+	assert.NoError(t, network.Run(events, tick.NewStepClock(now, time.Millisecond), time.Second, WithVirtualTime()))
+	_, err = network.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, 10, received)
+	assert.Equal(t, 5, nodes["b"].(*countingNode).count)
+}
+
+func TestTopologyErrors(t *testing.T) {
+	topology, err := ReadTopology(strings.NewReader(`
+nodes:
+  - {name: a, type: unknown}
+  - {name: b, type: channel, options: [{option: delay, model: fixed}]}
+  - {name: c, type: restrict}
+  - {name: c, type: endpoint}
+  - {name: d, type: channel, options: [{option: loss, model: random, possibility: 2}]}
+groups:
+  - {name: g, in: a, out: e}
+chains:
+  - [a, f]
+`))
+	assert.NoError(t, err)
+	_, _, err = topology.Build()
+	errs, ok := err.(Errors)
+	assert.True(t, ok)
+	assert.Equal(t, 7, len(errs))
+	for i, prefix := range []string{"node a:", "node b:", "node c:", "node c:", "node d:", "group g:", "chain 0:"} {
+		assert.True(t, strings.HasPrefix(errs[i].Error(), prefix), errs[i].Error())
+	}
+
+	_, err = ReadTopology(strings.NewReader("nodes: [{name: a, typo: endpoint}]"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,246 @@
+package ns_x
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/bytedance/ns-x/v2/base"
+	"hash/fnv"
+	"io"
+	"time"
+)
+
+// traceMagic is the header of trace files, followed by the version
+var traceMagic = []byte("NSXT\x01")
+
+// a trace is a sequence of records, one for each event handled, in the order handled, each record is encoded as:
+// varint of time since the previous record (or the start of the simulation) in nanoseconds, uvarint of the scheduling node, uvarint of the node bound,
+// uvarint of count of decisions, and decisions each encoded as:
+// 8 bytes of packet identity, a byte 1 if lost or 0, varint of delay in nanoseconds and varint of route
+// nodes are encoded as index in the network plus one, 0 if none
+
+// decision taken on a packet in a trace
+type decision struct {
+	packet uint64
+	base.Decision
+}
+
+// tracer records or replays the trace of a simulation
+type tracer interface {
+	// begin handling the event
+	begin(event base.Event) error
+	// end handling the event, which generated the given events
+	end(event base.Event, events []base.Event) error
+	// decide is the decision hook of nodes
+	decide(node base.Node, packet base.Packet, decide func() base.Decision) base.Decision
+	// finish the trace
+	finish() error
+}
+
+// ReplayError indicates the simulation replayed diverges from the trace
+type ReplayError struct {
+	// Time of the event diverges, in simulated clock
+	Time time.Time
+	// Reason of the divergence
+	Reason string
+}
+
+func (e *ReplayError) Error() string {
+	return fmt.Sprintf("replay diverges at %s: %s", e.Time, e.Reason)
+}
+
+// identify the packet for traces, 0 if the packet cannot be identified
+func identify(packet base.Packet) uint64 {
+	switch p := packet.(type) {
+	case base.RawPacket:
+		h := fnv.New64a()
+		_, _ = h.Write(p)
+		return h.Sum64()
+	case *base.SimulatePacket:
+		return identify(p.Data)
+	}
+	return 0
+}
+
+// indexes of nodes in the network plus one, 0 is reserved for none
+func indexes(nodes []base.Node) map[base.Node]uint64 {
+	result := make(map[base.Node]uint64, len(nodes))
+	for i, node := range nodes {
+		result[node] = uint64(i + 1)
+	}
+	return result
+}
+
+type recorder struct {
+	writer    *bufio.Writer
+	ids       map[base.Node]uint64
+	sources   map[base.Event]uint64 // scheduling node of events not handled yet
+	last      time.Time
+	decisions []decision
+	buffer    [binary.MaxVarintLen64]byte
+	err       error
+}
+
+func newRecorder(writer io.Writer, nodes []base.Node, start time.Time) *recorder {
+	r := &recorder{
+		writer:  bufio.NewWriter(writer),
+		ids:     indexes(nodes),
+		sources: map[base.Event]uint64{},
+		last:    start,
+	}
+	r.write(traceMagic)
+	return r
+}
+
+func (r *recorder) write(data []byte) {
+	if r.err == nil {
+		_, r.err = r.writer.Write(data)
+	}
+}
+
+func (r *recorder) varint(x int64) {
+	r.write(r.buffer[:binary.PutVarint(r.buffer[:], x)])
+}
+
+func (r *recorder) uvarint(x uint64) {
+	r.write(r.buffer[:binary.PutUvarint(r.buffer[:], x)])
+}
+
+func (r *recorder) begin(event base.Event) error {
+	r.decisions = r.decisions[:0]
+	return r.err
+}
+
+func (r *recorder) end(event base.Event, events []base.Event) error {
+	source := r.sources[event]
+	delete(r.sources, event)
+	r.varint(int64(event.Time().Sub(r.last)))
+	r.last = event.Time()
+	r.uvarint(source)
+	r.uvarint(r.ids[event.Node()])
+	r.uvarint(uint64(len(r.decisions)))
+	for _, d := range r.decisions {
+		binary.LittleEndian.PutUint64(r.buffer[:8], d.packet)
+		r.write(r.buffer[:8])
+		if d.Lost {
+			r.write([]byte{1})
+		} else {
+			r.write([]byte{0})
+		}
+		r.varint(int64(d.Delay))
+		r.varint(int64(d.Route))
+	}
+	if id := r.ids[event.Node()]; id != 0 {
+		for _, e := range events {
+			r.sources[e] = id
+		}
+	}
+	return r.err
+}
+
+func (r *recorder) decide(node base.Node, packet base.Packet, decide func() base.Decision) base.Decision {
+	d := decide()
+	r.decisions = append(r.decisions, decision{packet: identify(packet), Decision: d})
+	return d
+}
+
+func (r *recorder) finish() error {
+	if r.err == nil {
+		r.err = r.writer.Flush()
+	}
+	return r.err
+}
+
+type replayer struct {
+	reader    *bufio.Reader
+	ids       map[base.Node]uint64
+	last      time.Time
+	decisions []decision
+	index     int // index of the next decision to replay
+}
+
+func newReplayer(reader io.Reader, nodes []base.Node, start time.Time) (*replayer, error) {
+	r := &replayer{reader: bufio.NewReader(reader), ids: indexes(nodes), last: start}
+	magic := make([]byte, len(traceMagic))
+	if _, err := io.ReadFull(r.reader, magic); err != nil || string(magic) != string(traceMagic) {
+		return nil, errors.New("not a trace of supported version")
+	}
+	return r, nil
+}
+
+// read the next record, io.EOF if the trace ends
+func (r *replayer) read() (t time.Time, node uint64, err error) {
+	delta, err := binary.ReadVarint(r.reader)
+	if err != nil {
+		return
+	}
+	t = r.last.Add(time.Duration(delta))
+	if _, err = binary.ReadUvarint(r.reader); err != nil {
+		return
+	}
+	if node, err = binary.ReadUvarint(r.reader); err != nil {
+		return
+	}
+	count, err := binary.ReadUvarint(r.reader)
+	if err != nil {
+		return
+	}
+	r.decisions = r.decisions[:0]
+	r.index = 0
+	packet := make([]byte, 9)
+	for i := uint64(0); i < count; i++ {
+		if _, err = io.ReadFull(r.reader, packet); err != nil {
+			return
+		}
+		d := decision{packet: binary.LittleEndian.Uint64(packet), Decision: base.Decision{Lost: packet[8] == 1}}
+		var delay, route int64
+		if delay, err = binary.ReadVarint(r.reader); err != nil {
+			return
+		}
+		if route, err = binary.ReadVarint(r.reader); err != nil {
+			return
+		}
+		d.Delay, d.Route = time.Duration(delay), int(route)
+		r.decisions = append(r.decisions, d)
+	}
+	r.last = t
+	return
+}
+
+func (r *replayer) begin(event base.Event) error {
+	t, node, err := r.read()
+	if err == io.EOF {
+		return &ReplayError{Time: event.Time(), Reason: "trace ends"}
+	}
+	if err != nil {
+		return err
+	}
+	if !t.Equal(event.Time()) || node != r.ids[event.Node()] {
+		return &ReplayError{Time: event.Time(), Reason: fmt.Sprintf("expect event at %s", t)}
+	}
+	return nil
+}
+
+func (r *replayer) end(event base.Event, events []base.Event) error {
+	if r.index < len(r.decisions) {
+		return &ReplayError{Time: event.Time(), Reason: "decisions not taken"}
+	}
+	return nil
+}
+
+func (r *replayer) decide(node base.Node, packet base.Packet, decide func() base.Decision) base.Decision {
+	if r.index >= len(r.decisions) {
+		panic(&ReplayError{Time: r.last, Reason: "unexpected decision"})
+	}
+	d := r.decisions[r.index]
+	r.index++
+	if id := identify(packet); id != 0 && d.packet != 0 && id != d.packet {
+		panic(&ReplayError{Time: r.last, Reason: "unexpected packet"})
+	}
+	return d.Decision
+}
+
+func (r *replayer) finish() error {
+	return nil
+}
@@ -0,0 +1,75 @@
+package ns_x
+
+import (
+	"bytes"
+	"errors"
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/math"
+	"github.com/bytedance/ns-x/v2/node"
+	"github.com/bytedance/ns-x/v2/tick"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// random build a network routing packets randomly through lossy channels with random delay, return packets received
+func random(t *testing.T, seed int64, count int, configs ...Config) ([]trace, error) {
+	now := time.Unix(0, 0)
+	r := rand.New(rand.NewSource(seed))
+	builder := NewBuilder().
+		Chain().
+		NodeWithName("sender", node.NewEndpointNode()).
+		NodeWithName("scatter", node.NewScatterNode(node.WithRouteSelector(func(packet base.Packet, nodes []base.Node) base.Node {
+			return nodes[r.Intn(len(nodes))]
+		}))).
+		Chain().
+		NodeWithName("gather", node.NewGatherNode()).
+		NodeWithName("receiver", node.NewEndpointNode())
+	for i := 0; i < 3; i++ {
+		builder.Chain().
+			NodeOfName("scatter").
+			Node(node.NewChannelNode(node.WithLoss(math.NewRandomLoss(0.3, r)), node.WithDelay(math.NewUniformDelay(time.Second, r)))).
+			NodeOfName("gather")
+	}
+	network, nodes, err := builder.Build()
+	assert.NoError(t, err)
+	var traces []trace
+	nodes["receiver"].(*node.EndpointNode).Receive(func(packet base.Packet, now time.Time) []base.Event {
+		traces = append(traces, trace{packet: int(packet.(base.RawPacket)[0]), time: now})
+		return nil
+	})
+	sender := nodes["sender"].(*node.EndpointNode)
+	events := make([]base.Event, 0, count)
+	for i := 0; i < count; i++ {
+		events = append(events, sender.Send(base.RawPacket{byte(i)}, now.Add(time.Duration(i)*time.Millisecond)))
+	}
+	configs = append(configs, WithVirtualTime())
+	assert.NoError(t, network.Run(events, tick.NewStepClock(now, time.Millisecond), time.Minute, configs...))
+	_, err = network.Wait()
+	return traces, err
+}
+
+func TestRecordReplay(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	expected, err := random(t, 1, 64, WithRecord(buffer))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, expected)
+	different, err := random(t, 2, 64)
+	assert.NoError(t, err)
+	assert.NotEqual(t, expected, different)
+	replayed, err := random(t, 2, 64, WithReplay(bytes.NewReader(buffer.Bytes())))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, replayed)
+}
+
+func TestReplayDiverge(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	_, err := random(t, 1, 16, WithRecord(buffer))
+	assert.NoError(t, err)
+	_, err = random(t, 1, 32, WithReplay(bytes.NewReader(buffer.Bytes())))
+	replayError := &ReplayError{}
+	assert.True(t, errors.As(err, &replayError))
+	network := NewNetwork(nil)
+	assert.Error(t, network.Run(nil, tick.NewRealClock(), time.Second, WithReplay(bytes.NewReader(nil))))
+}
@@ -0,0 +1,226 @@
+package ns_x
+
+import (
+	"fmt"
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/node"
+	"strconv"
+	"strings"
+)
+
+// ValidationError indicates a problem of the network described by the builder
+type ValidationError struct {
+	// Nodes involved, such as the node with wrong count of next nodes, or nodes of a cycle
+	Nodes []base.Node
+	// Names of the nodes, or "#id" for nodes without names
+	Names []string
+	// Problem found
+	Problem string
+}
+
+func (e *ValidationError) Error() string {
+	return "node " + strings.Join(e.Names, " -> ") + ": " + e.Problem
+}
+
+// forwarding whether the node is a built-in node transferring packets received to next nodes
+func forwarding(n base.Node) bool {
+	switch n.(type) {
+	case *node.ChannelNode, *node.RestrictNode, *node.GatherNode, *node.ScatterNode, *node.BroadcastNode:
+		return true
+	}
+	return false
+}
+
+// instant whether packets may pass through the node without any delay, false if unknown
+// scatter nodes are excluded, since each packet takes only the route selected, such as by a routing table,
+// so that cycles through them, like duplex links between routers, don't loop unless routes do
+func instant(n base.Node) bool {
+	if delayer, ok := n.(base.Delayer); ok {
+		delay, known := delayer.MinDelay()
+		return known && delay <= 0
+	}
+	switch n.(type) {
+	case *node.RestrictNode, *node.GatherNode, *node.BroadcastNode:
+		return true
+	}
+	return false
+}
+
+// name of the node for diagnostics, "#id" if unnamed
+func (b *builder) name(n base.Node) string {
+	if name, ok := b.nodeToName[n]; ok {
+		return strconv.Quote(name) + " (" + typeName(n) + ")"
+	}
+	return "#" + strconv.Itoa(b.nodeToID[n]) + " (" + typeName(n) + ")"
+}
+
+func (b *builder) problem(problem string, nodes ...base.Node) *ValidationError {
+	err := &ValidationError{Nodes: nodes, Problem: problem}
+	for _, n := range nodes {
+		err.Names = append(err.Names, b.name(n))
+	}
+	return err
+}
+
+func (b *builder) Validate() error {
+	errs := append(Errors(nil), b.errors...)
+	nodes := b.ordered()
+	// count of next nodes
+	for _, n := range nodes {
+		count := len(b.connections[n])
+		switch n.(type) {
+		case *node.ChannelNode, *node.RestrictNode, *node.GatherNode:
+			if count != 1 {
+				errs = append(errs, b.problem(fmt.Sprintf("must have exactly 1 next node, got %d", count), n))
+			}
+		case *node.EndpointNode:
+			if count > 1 {
+				errs = append(errs, b.problem(fmt.Sprintf("can have at most 1 next node, got %d", count), n))
+			}
+		case *node.ScatterNode:
+			if count == 0 {
+				errs = append(errs, b.problem("has no routes", n))
+			}
+		}
+	}
+	// packets are only originated by endpoints, or nodes not built-in
+	reached := map[base.Node]bool{}
+	var reach func(n base.Node)
+	reach = func(n base.Node) {
+		if reached[n] {
+			return
+		}
+		reached[n] = true
+		for _, next := range b.connections[n] {
+			reach(next)
+		}
+	}
+	for _, n := range nodes {
+		if !forwarding(n) {
+			reach(n)
+		}
+	}
+	for _, n := range nodes {
+		if !reached[n] {
+			errs = append(errs, b.problem("is unreachable from any endpoint", n))
+		}
+	}
+	// packets sent by endpoints must be able to arrive at any receiver, which is not a forwarding node
+	for _, n := range nodes {
+		if _, ok := n.(*node.EndpointNode); ok && len(b.connections[n]) > 0 && !b.delivers(n) {
+			errs = append(errs, b.problem("cannot reach any receiver", n))
+		}
+	}
+	for _, cycle := range b.instantCycles(nodes) {
+		errs = append(errs, b.problem("forms a cycle without delay, where packets would loop forever at the same time", cycle...))
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// delivers whether packets sent by the endpoint can arrive at any receiver through forwarding nodes
+func (b *builder) delivers(endpoint base.Node) bool {
+	visited := map[base.Node]bool{}
+	queue := append([]base.Node(nil), b.connections[endpoint]...)
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if !forwarding(n) {
+			return true
+		}
+		if visited[n] {
+			continue
+		}
+		visited[n] = true
+		queue = append(queue, b.connections[n]...)
+	}
+	return false
+}
+
+// instantCycles find cycles formed by nodes which packets may pass through without delay, one cycle for each
+// strongly connected component of such nodes, by Tarjan's algorithm
+func (b *builder) instantCycles(nodes []base.Node) [][]base.Node {
+	index := map[base.Node]int{}
+	low := map[base.Node]int{}
+	onStack := map[base.Node]bool{}
+	var stack []base.Node
+	var result [][]base.Node
+	var connect func(n base.Node)
+	connect = func(n base.Node) {
+		index[n] = len(index)
+		low[n] = index[n]
+		stack = append(stack, n)
+		onStack[n] = true
+		for _, next := range b.connections[n] {
+			if !instant(next) {
+				continue
+			}
+			if _, ok := index[next]; !ok {
+				connect(next)
+				if low[next] < low[n] {
+					low[n] = low[next]
+				}
+			} else if onStack[next] && index[next] < low[n] {
+				low[n] = index[next]
+			}
+		}
+		if low[n] != index[n] {
+			return
+		}
+		var component []base.Node
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == n {
+				break
+			}
+		}
+		if len(component) > 1 || contains(b.connections[n], n) {
+			result = append(result, b.cycle(component))
+		}
+	}
+	for _, n := range nodes {
+		if _, ok := index[n]; !ok && instant(n) {
+			connect(n)
+		}
+	}
+	return result
+}
+
+// cycle find a cycle in the strongly connected component, starting from the node with the least id
+func (b *builder) cycle(component []base.Node) []base.Node {
+	members := map[base.Node]bool{}
+	start := component[0]
+	for _, n := range component {
+		members[n] = true
+		if b.nodeToID[n] < b.nodeToID[start] {
+			start = n
+		}
+	}
+	// breadth first search back to the start, so that the cycle is the shortest one through the start
+	previous := map[base.Node]base.Node{start: nil}
+	queue := []base.Node{start}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, next := range b.connections[n] {
+			if next == start {
+				var cycle []base.Node
+				for c := n; c != nil; c = previous[c] {
+					cycle = append([]base.Node{c}, cycle...)
+				}
+				return append(cycle, start)
+			}
+			if _, ok := previous[next]; ok || !members[next] {
+				continue
+			}
+			previous[next] = n
+			queue = append(queue, next)
+		}
+	}
+	return component
+}
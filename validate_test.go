@@ -0,0 +1,56 @@
+package ns_x
+
+import (
+	"errors"
+	"github.com/bytedance/ns-x/v2/base"
+	"github.com/bytedance/ns-x/v2/math"
+	"github.com/bytedance/ns-x/v2/node"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	selector := node.WithRouteSelector(func(packet base.Packet, nodes []base.Node) base.Node {
+		return nodes[0]
+	})
+	builder := NewBuilder().
+		Chain().
+		NodeWithName("sender", node.NewEndpointNode()).
+		NodeWithName("split", node.NewBroadcastNode()).
+		NodeWithName("slow", node.NewChannelNode(node.WithDescribedDelay(math.NewFixedDelayModel(time.Millisecond)))).
+		NodeWithName("receiver", node.NewEndpointNode()).
+		Chain().
+		NodeOfName("split").
+		NodeWithName("fast", node.NewChannelNode()).
+		NodeWithName("gather", node.NewGatherNode()).
+		NodeOfName("split").
+		Chain().
+		NodeOfName("gather").
+		NodeOfName("receiver").
+		Chain().
+		NodeWithName("orphan", node.NewRestrictNode(node.WithPPSLimit(1, -1))).
+		NodeWithName("lost", node.NewBroadcastNode()).
+		Chain().
+		NodeWithName("alone", node.NewEndpointNode()).
+		NodeWithName("route", node.NewScatterNode(selector))
+	_, _, err := builder.Build()
+	var errs Errors
+	assert.True(t, errors.As(err, &errs))
+	var messages []string
+	for _, e := range errs {
+		validation := &ValidationError{}
+		assert.True(t, errors.As(e, &validation))
+		messages = append(messages, e.Error())
+	}
+	assert.Equal(t, []string{
+		`node "gather" (GatherNode): must have exactly 1 next node, got 2`,
+		`node "route" (ScatterNode): has no routes`,
+		`node "orphan" (RestrictNode): is unreachable from any endpoint`,
+		`node "lost" (BroadcastNode): is unreachable from any endpoint`,
+		`node "alone" (EndpointNode): cannot reach any receiver`,
+		`node "split" (BroadcastNode) -> "fast" (ChannelNode) -> "gather" (GatherNode) -> "split" (BroadcastNode): ` +
+			`forms a cycle without delay, where packets would loop forever at the same time`,
+	}, messages)
+	assert.NoError(t, exported().Validate())
+}